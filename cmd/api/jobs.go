@@ -0,0 +1,128 @@
+package main
+
+import (
+	"blooters/internal/db"
+	"blooters/internal/ingest"
+	"blooters/internal/metrics"
+	"blooters/internal/reddit"
+	"blooters/internal/scheduler"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// registerJobs adds the ingestion and maintenance jobs to the scheduler.
+// Intervals can be tuned with the *_INTERVAL environment variables.
+func registerJobs(s *scheduler.Scheduler, store *db.Store, sources []ingest.GoalSource) {
+	// Fetch goals from every source and store them in the database
+	s.Add(scheduler.Job{
+		Name:       "ingest-goals",
+		Interval:   envDuration("INGEST_INTERVAL", 10*time.Second),
+		Jitter:     time.Second,
+		Timeout:    2 * time.Minute,
+		RunAtStart: true,
+		Run: func(ctx context.Context) error {
+			goals, rejected := ingest.FetchAll(ctx, sources)
+
+			// Let a store that has started finish even if we are shutting down,
+			// rather than leaving a game half written
+			storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 20*time.Second)
+			defer cancel()
+
+			result, err := store.StoreGoals(storeCtx, goals)
+			if err != nil {
+				metrics.GoalsStoreCount.WithLabelValues("error").Inc()
+				return fmt.Errorf("error storing goals: %w", err)
+			}
+			metrics.GoalsStoreCount.WithLabelValues("success").Inc()
+
+			inserted, updated, skipped := result.Totals()
+			metrics.GoalsStored.WithLabelValues("inserted").Add(float64(inserted))
+			metrics.GoalsStored.WithLabelValues("updated").Add(float64(updated))
+			metrics.GoalsStored.WithLabelValues("skipped").Add(float64(skipped))
+			if inserted > 0 || updated > 0 {
+				log.Printf("Stored goals: %d inserted, %d updated, %d skipped", inserted, updated, skipped)
+			}
+			// Quarantine rejected posts before moving the cursors past them
+			if err := store.StoreParseFailures(storeCtx, rejected); err != nil {
+				return fmt.Errorf("error storing parse failures: %w", err)
+			}
+			ingest.CommitAll(storeCtx, sources)
+			return nil
+		},
+	})
+
+	// Poll the mirrors comments of recent goals for new mirrors
+	s.Add(scheduler.Job{
+		Name:     "populate-mirrors",
+		Interval: envDuration("MIRRORS_INTERVAL", 10*time.Second),
+		Jitter:   time.Second,
+		Timeout:  time.Minute,
+		Run: func(ctx context.Context) error {
+			if err := reddit.PopulateMirrors(ctx, reddit.DefaultClient(), store); err != nil {
+				metrics.MirrorsPopulateCount.WithLabelValues("error").Inc()
+				return fmt.Errorf("error populating mirrors: %w", err)
+			}
+			metrics.MirrorsPopulateCount.WithLabelValues("success").Inc()
+			return nil
+		},
+	})
+
+	// Remove old goals
+	s.Add(scheduler.Job{
+		Name:     "remove-old-goals",
+		Interval: envDuration("CLEANUP_INTERVAL", 5*time.Hour),
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			if err := store.RemoveOldGoals(ctx); err != nil {
+				metrics.RemoveOldGoalsCount.WithLabelValues("error").Inc()
+				return fmt.Errorf("error removing old goals: %w", err)
+			}
+			metrics.RemoveOldGoalsCount.WithLabelValues("success").Inc()
+			return nil
+		},
+	})
+
+	// Call the Ping API to keep the server active
+	keepaliveURL := getEnv("KEEPALIVE_URL", "https://absolute-blooters.onrender.com/api/ping")
+	s.Add(scheduler.Job{
+		Name:     "keepalive",
+		Interval: envDuration("KEEPALIVE_INTERVAL", 10*time.Second),
+		Timeout:  10 * time.Second,
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, "GET", keepaliveURL, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close() // close immediately
+			return nil
+		},
+	})
+}
+
+func getEnv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
+
+func envDuration(k string, def time.Duration) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s=%q, using %v", k, v, def)
+		return def
+	}
+	return d
+}
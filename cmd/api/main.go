@@ -2,85 +2,83 @@ package main
 
 import (
 	"blooters/internal/db"
-	"blooters/internal/metrics"
-	"blooters/internal/reddit"
+	"blooters/internal/events"
+	"blooters/internal/ingest"
+	"blooters/internal/scheduler"
 	"blooters/internal/server"
+	"context"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 func main() {
-	if err := db.Init(); err != nil {
+	// Cancelled on SIGINT/SIGTERM (Render sends SIGTERM on every redeploy)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	// Goal, score and mirrors changes for /api/games/stream
+	broker := events.NewBroker(256)
+
+	store, err := db.Open(ctx, db.ConfigFromEnv(), broker)
+	if err != nil {
 		log.Fatalf("failed to initialize database: %v", err)
 	}
 	defer func() {
-		if err := db.Close(); err != nil {
+		if err := store.Close(); err != nil {
 			log.Printf("error closing db: %v", err)
 		}
 	}()
 
-	srv := server.NewServer()
+	sources, err := ingest.SourcesFromEnv(store)
+	if err != nil {
+		log.Fatalf("failed to configure goal sources: %v", err)
+	}
+
+	sched := scheduler.New()
+	registerJobs(sched, store, sources)
+
+	srv := server.NewServer(store, broker, sched)
 
 	go func() {
 		if err := srv.Start(":8080"); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
+			log.Printf("Failed to start server: %v", err)
+			stop()
 		}
 	}()
 
-	// Periodically (10s) fetch goals from Reddit and store in database
-	ticker := time.NewTicker(10 * time.Second)
+	jobsDone := make(chan struct{})
 	go func() {
-		for range ticker.C {
-			log.Println("GOALS FETCHED HERE (ticker triggered)")
-			goals, err := reddit.FetchGoals()
-			if err != nil {
-				log.Printf("Error fetching goals: %s\n", err)
-				metrics.GoalsFetchCount.WithLabelValues("error").Inc()
-				continue
-			}
-			metrics.GoalsFetchCount.WithLabelValues("success").Inc()
-
-			if err := db.StoreGoals(goals); err != nil {
-				log.Printf("Error storing goals: %s\n", err)
-				metrics.GoalsStoreCount.WithLabelValues("error").Inc()
-			} else {
-				log.Printf("StoreGoals end")
-				metrics.GoalsStoreCount.WithLabelValues("success").Inc()
-			}
+		sched.Run(ctx)
+		close(jobsDone)
+	}()
 
-			// Populate mirrors for goals that don't have them
-			if err := reddit.PopulateMirrors(); err != nil {
-				log.Printf("Error populating mirrors: %s\n", err)
-				metrics.MirrorsPopulateCount.WithLabelValues("error").Inc()
-			} else {
-				metrics.MirrorsPopulateCount.WithLabelValues("success").Inc()
-			}
+	<-ctx.Done()
+	log.Println("Shutting down...")
 
-			// Call the Ping API to keep the server active:
-			resp, err := http.Get("https://absolute-blooters.onrender.com/api/ping")
-			if err != nil {
-				log.Fatal(err)
-			}
-			resp.Body.Close() // close immediately
-		}
-	}()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", 25*time.Second))
+	defer cancel()
 
-	// Periodically (5h) remove old goals
-	tickerLimit := time.NewTicker(5 * time.Hour)
-	go func() {
-		for range tickerLimit.C {
-			if err := db.RemoveOldGoals(); err != nil {
-				log.Printf("Error removing old goals: %s\n", err)
-				metrics.RemoveOldGoalsCount.WithLabelValues("error").Inc()
-			} else {
-				log.Printf("RemoveOldGoals end")
-				metrics.RemoveOldGoalsCount.WithLabelValues("success").Inc()
-			}
+	// Drain HTTP connections while the jobs finish their current run
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error shutting down server: %v", err)
+	}
 
-		}
-	}()
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		log.Printf("timed out waiting for jobs to finish")
+	}
 
-	// Keep the program running
-	select {}
+	srv.FlushMetrics(shutdownCtx)
+	log.Println("Shutdown complete")
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"blooters/internal/db"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up         apply all pending migrations and the team alias seed
+  down [n]   revert the last n applied migrations (default 1)
+  status     list migrations and when they were applied`
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	cfg := db.ConfigFromEnv()
+	cfg.AutoMigrate = false
+	store, err := db.Open(ctx, cfg, nil)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "up":
+		if err := store.Migrate(ctx); err != nil {
+			return err
+		}
+		return store.SeedTeams(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations to revert: %q", args[1])
+			}
+		}
+		return store.MigrateDown(ctx, steps)
+	case "status":
+		status, err := store.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range status {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
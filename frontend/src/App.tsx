@@ -3,6 +3,12 @@ import { API_BASE_URL } from "./config";
 import { useTheme } from './contexts/ThemeContext';
 import './App.css';
 
+interface Mirror {
+  host: string;
+  label: string;
+  url: string;
+}
+
 interface Goal {
   id: number;
   game_id: number;
@@ -13,12 +19,31 @@ interface Goal {
   minute: string;
   url: string;
   reddit_url: string;
-  mirrors: string;
+  mirrors_url: string;
+  mirrors: Mirror[];
   home_score: number;
   away_score: number;
   away: boolean;
+  side: 'home' | 'away' | 'unknown';
+  type: 'open_play' | 'penalty' | 'own_goal' | 'free_kick';
+  minute_base: number;
+  minute_added: number;
+  disallowed: boolean;
 }
 
+const goalTypeLabels: Record<Goal['type'], string> = {
+  open_play: '',
+  penalty: ' (pen)',
+  own_goal: ' (og)',
+  free_kick: ' (fk)',
+};
+
+const goalNotes = (goal: Goal) =>
+  (goalTypeLabels[goal.type] ?? '') + (goal.disallowed ? ' (disallowed)' : '');
+
+const goalTeam = (goal: Goal) =>
+  goal.side === 'home' ? ` (${goal.home_team})` : goal.side === 'away' ? ` (${goal.away_team})` : '';
+
 interface Game {
   id: number;
   home_team: string;
@@ -41,23 +66,44 @@ function App() {
   const [gamesError, setGamesError] = useState<string | null>(null);
 
   useEffect(() => {
-    console.log('Fetching games...');
-    fetch(`${API_BASE_URL}/api/games`)
-      .then((response) => {
-        if (!response.ok) {
-          throw new Error(`HTTP error! Status: ${response.status}`);
-        }
-        return response.json();
-      })
-      .then((data: GamesResponse) => {
-        setGamesData(data.games);
-        setLoadingGames(false);
-      })
-      .catch((err) => {
-        console.error("Fetch games error:", err);
-        setGamesError(err.message);
-        setLoadingGames(false);
-      });
+    const loadGames = () => {
+      console.log('Fetching games...');
+      fetch(`${API_BASE_URL}/api/games`)
+        .then((response) => {
+          if (!response.ok) {
+            throw new Error(`HTTP error! Status: ${response.status}`);
+          }
+          return response.json();
+        })
+        .then((data: GamesResponse) => {
+          setGamesData(data.games);
+          setGamesError(null);
+          setLoadingGames(false);
+        })
+        .catch((err) => {
+          console.error("Fetch games error:", err);
+          setGamesError(err.message);
+          setLoadingGames(false);
+        });
+    };
+
+    loadGames();
+
+    // Refetch whenever the backend reports a new goal, score, mirrors link,
+    // correction or other change. EventSource reconnects on its own and
+    // resumes with Last-Event-ID.
+    let refetchTimer: ReturnType<typeof setTimeout> | undefined;
+    const scheduleRefetch = () => {
+      clearTimeout(refetchTimer);
+      refetchTimer = setTimeout(loadGames, 500);
+    };
+    const stream = new EventSource(`${API_BASE_URL}/api/games/stream`);
+    ['goal', 'score', 'mirrors', 'correction', 'change', 'reset'].forEach((type) => stream.addEventListener(type, scheduleRefetch));
+
+    return () => {
+      clearTimeout(refetchTimer);
+      stream.close();
+    };
   }, []);
 
   return (
@@ -118,20 +164,25 @@ function App() {
                               {goal.url ? (
                                 <div className="goal-links">
                                   <a href={goal.url} target="_blank" rel="noopener noreferrer" className="goal-link">
-                                    <strong>{goal.goalscorer}</strong> ({goal.minute}') - {goal.home_score}-{goal.away_score}
-                                    {goal.away ? ` (${goal.away_team})` : ` (${goal.home_team})`}
+                                    <strong>{goal.goalscorer}</strong>{goalNotes(goal)} ({goal.minute}') - {goal.home_score}-{goal.away_score}
+                                    {goalTeam(goal)}
                                     <span className="watch-text"> ▶ Watch</span>
                                   </a>
-                                  {goal.mirrors && (
-                                    <a href={goal.mirrors} target="_blank" rel="noopener noreferrer" className="mirror-link">
-                                      🔄 Mirrors
+                                  {goal.mirrors?.map((mirror) => (
+                                    <a key={mirror.url} href={mirror.url} target="_blank" rel="noopener noreferrer" className="mirror-link">
+                                      🔄 {mirror.label || mirror.host}
+                                    </a>
+                                  ))}
+                                  {goal.mirrors_url && (
+                                    <a href={goal.mirrors_url} target="_blank" rel="noopener noreferrer" className="mirror-link">
+                                      💬 Mirrors thread
                                     </a>
                                   )}
                                 </div>
                               ) : (
                                 <span>
-                                  <strong>{goal.goalscorer}</strong> ({goal.minute}') - {goal.home_score}-{goal.away_score}
-                                  {goal.away ? ` (${goal.away_team})` : ` (${goal.home_team})`}
+                                  <strong>{goal.goalscorer}</strong>{goalNotes(goal)} ({goal.minute}') - {goal.home_score}-{goal.away_score}
+                                  {goalTeam(goal)}
                                 </span>
                               )}
                             </li>
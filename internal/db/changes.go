@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"blooters/internal/models"
+)
+
+// changeRetention is how long tombstones of deleted games and goals are kept
+// in the change feed. Clients that last synced before that must start over.
+const changeRetention = 7 * 24 * time.Hour
+
+// ErrResync is returned by Changes when tombstones the client needs were
+// pruned, so it has to sync from scratch.
+var ErrResync = errors.New("revision too old, sync from scratch")
+
+// ChangeSet is what changed after a revision, as current rows and tombstones.
+type ChangeSet struct {
+	// Revision is the revision to ask for changes after next time.
+	Revision int64
+	// Games are the changed games, with all their goals.
+	Games []models.Game
+	// Goals are the changed goals.
+	Goals []models.Goal
+	// Deleted are the games and goals removed since.
+	Deleted []models.Tombstone
+	// More is set when the changes were cut at the limit.
+	More bool
+}
+
+// Changes returns up to limit games and goals written after revision since, in
+// the order they were written. Since 0 returns everything, as a first sync. It
+// is ErrResync when tombstones after since were pruned already.
+func (s *Store) Changes(ctx context.Context, since int64, limit int) (ChangeSet, error) {
+	set := ChangeSet{Revision: since, Games: []models.Game{}, Goals: []models.Goal{}, Deleted: []models.Tombstone{}}
+
+	// One snapshot, so that the rows match the revisions
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return set, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var floor int64
+	if err := tx.QueryRowContext(ctx, "SELECT revision FROM changes_floor").Scan(&floor); err != nil {
+		return set, fmt.Errorf("failed to query changes floor: %w", err)
+	}
+	if since > 0 && since < floor {
+		return set, ErrResync
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT entity, entity_id, revision, deleted FROM changes
+		 WHERE revision > $1 ORDER BY revision LIMIT $2`, since, limit+1)
+	if err != nil {
+		return set, fmt.Errorf("failed to query changes: %w", err)
+	}
+	defer rows.Close()
+
+	var gameIDs, goalIDs []int
+	for rows.Next() {
+		if len(gameIDs)+len(goalIDs)+len(set.Deleted) == limit {
+			set.More = true
+			break
+		}
+		var t models.Tombstone
+		var deleted bool
+		if err := rows.Scan(&t.Entity, &t.ID, &set.Revision, &deleted); err != nil {
+			return set, fmt.Errorf("failed to scan change: %w", err)
+		}
+		switch {
+		case deleted:
+			set.Deleted = append(set.Deleted, t)
+		case t.Entity == "game":
+			gameIDs = append(gameIDs, t.ID)
+		default:
+			goalIDs = append(goalIDs, t.ID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return set, fmt.Errorf("failed to query changes: %w", err)
+	}
+	rows.Close()
+
+	if len(gameIDs) > 0 {
+		if set.Games, err = queryGames(ctx, tx, gameIDs); err != nil {
+			return set, err
+		}
+	}
+	if len(goalIDs) > 0 {
+		if set.Goals, err = queryGoals(ctx, tx, "id = ANY($1::int[]) ORDER BY id", goalIDs); err != nil {
+			return set, err
+		}
+	}
+	return set, nil
+}
+
+// queryGames reads the games with the given IDs and all their goals.
+func queryGames(ctx context.Context, q queryer, ids []int) ([]models.Game, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+gameColumns+" FROM games WHERE id = ANY($1::int[]) ORDER BY id", ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query games: %w", err)
+	}
+	defer rows.Close()
+
+	games := []models.Game{}
+	for rows.Next() {
+		var g models.Game
+		if err := scanGame(rows, &g); err != nil {
+			return nil, fmt.Errorf("failed to scan game: %w", err)
+		}
+		games = append(games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query games: %w", err)
+	}
+	rows.Close()
+
+	if err := attachGoals(ctx, q, games, GamesFilter{}); err != nil {
+		return nil, fmt.Errorf("failed to query goals: %w", err)
+	}
+	return games, nil
+}
+
+// pruneChanges drops the tombstones older than changeRetention and raises the
+// floor clients must have synced past to theirs.
+func pruneChanges(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx,
+		`WITH pruned AS (
+		   DELETE FROM changes WHERE deleted AND changed_at < now() - make_interval(secs => $1)
+		   RETURNING revision
+		 )
+		 UPDATE changes_floor SET revision = GREATEST(revision, (SELECT max(revision) FROM pruned))`,
+		changeRetention.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to prune changes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"blooters/internal/models"
+)
+
+func TestChanges(t *testing.T) {
+	s := openTestStore(t)
+	seedGames(t, s, 3, 2)
+	ctx := context.Background()
+
+	all, err := s.Changes(ctx, 0, 100)
+	if err != nil {
+		t.Fatalf("Changes(0) error = %v", err)
+	}
+	if len(all.Games) != 3 || len(all.Goals) != 6 || len(all.Deleted) != 0 || all.More {
+		t.Fatalf("Changes(0) = %d games, %d goals, %d deleted, more %v; want 3, 6, 0, false",
+			len(all.Games), len(all.Goals), len(all.Deleted), all.More)
+	}
+
+	// Paging through gets the same revision
+	since := int64(0)
+	for {
+		page, err := s.Changes(ctx, since, 4)
+		if err != nil {
+			t.Fatalf("Changes(%d) error = %v", since, err)
+		}
+		since = page.Revision
+		if !page.More {
+			break
+		}
+	}
+	if since != all.Revision {
+		t.Errorf("paged revision = %d, want %d", since, all.Revision)
+	}
+
+	game := all.Games[0]
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM games WHERE id = $1", game.ID); err != nil {
+		t.Fatal(err)
+	}
+	delta, err := s.Changes(ctx, all.Revision, 100)
+	if err != nil {
+		t.Fatalf("Changes(%d) error = %v", all.Revision, err)
+	}
+	want := map[models.Tombstone]bool{{Entity: "game", ID: game.ID}: true}
+	for _, gl := range game.Goals {
+		want[models.Tombstone{Entity: "goal", ID: gl.ID}] = true
+	}
+	if len(delta.Deleted) != len(want) || len(delta.Games) != 0 || len(delta.Goals) != 0 {
+		t.Fatalf("Changes(%d) = %+v, want only the tombstones of game %d", all.Revision, delta, game.ID)
+	}
+	for _, d := range delta.Deleted {
+		if !want[d] {
+			t.Errorf("unexpected tombstone %+v", d)
+		}
+	}
+
+	// Pruned tombstones send clients that hadn't seen them back to the start
+	if _, err := s.db.ExecContext(ctx, "UPDATE changes SET changed_at = now() - interval '30 days' WHERE deleted"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveOldGoals(ctx); err != nil {
+		t.Fatalf("RemoveOldGoals() error = %v", err)
+	}
+	if _, err := s.Changes(ctx, all.Revision, 100); !errors.Is(err, ErrResync) {
+		t.Errorf("Changes(%d) error = %v, want ErrResync", all.Revision, err)
+	}
+	if _, err := s.Changes(ctx, delta.Revision, 100); err != nil {
+		t.Errorf("Changes(%d) error = %v", delta.Revision, err)
+	}
+}
@@ -0,0 +1,541 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"blooters/internal/events"
+	"blooters/internal/models"
+	"blooters/internal/names"
+)
+
+// ErrInvalid is returned, wrapped with the reason, for a correction that
+// can't be applied.
+var ErrInvalid = errors.New("invalid")
+
+// Audit actions.
+const (
+	auditUpdate   = "update"
+	auditReassign = "reassign"
+	auditMerge    = "merge"
+)
+
+// GoalPatch is a manual correction of a goal. Nil fields are left as they are;
+// the others are locked against ingestion, see models.Goal.LockedFields.
+type GoalPatch struct {
+	Goalscorer  *string `json:"goalscorer"`
+	MinuteBase  *int    `json:"minute_base"`
+	MinuteAdded *int    `json:"minute_added"`
+	Type        *string `json:"type"`
+	Side        *string `json:"side"`
+	Disallowed  *bool   `json:"disallowed"`
+	HomeScore   *int    `json:"home_score"`
+	AwayScore   *int    `json:"away_score"`
+	MirrorsURL  *string `json:"mirrors_url"`
+	GameID      *int    `json:"game_id"` // reassign the goal to another game
+}
+
+func (p GoalPatch) validate() error {
+	switch {
+	case p == GoalPatch{}:
+		return fmt.Errorf("%w: nothing to change", ErrInvalid)
+	case p.Type != nil && !models.ValidGoalType(*p.Type):
+		return fmt.Errorf("%w: type must be open_play, penalty, own_goal or free_kick", ErrInvalid)
+	case p.Side != nil && *p.Side != models.SideHome && *p.Side != models.SideAway && *p.Side != models.SideUnknown:
+		return fmt.Errorf("%w: side must be home, away or unknown", ErrInvalid)
+	case negative(p.MinuteBase, p.MinuteAdded, p.HomeScore, p.AwayScore):
+		return fmt.Errorf("%w: minutes and scores can't be negative", ErrInvalid)
+	case p.GameID != nil && *p.GameID <= 0:
+		return fmt.Errorf("%w: game_id must be a game id", ErrInvalid)
+	}
+	return nil
+}
+
+// apply copies the set fields onto g and returns the fields to lock.
+func (p GoalPatch) apply(g *models.Goal) []string {
+	var locked []string
+	if p.Goalscorer != nil {
+		g.Goalscorer = names.PlayerName(*p.Goalscorer)
+		locked = append(locked, "goalscorer")
+	}
+	if p.MinuteBase != nil || p.MinuteAdded != nil {
+		if p.MinuteBase != nil {
+			g.MinuteBase = *p.MinuteBase
+		}
+		if p.MinuteAdded != nil {
+			g.MinuteAdded = *p.MinuteAdded
+		}
+		g.Minute = formatMinute(g.MinuteBase, g.MinuteAdded)
+		locked = append(locked, "minute")
+	}
+	if p.Type != nil {
+		g.Type = *p.Type
+		locked = append(locked, "type")
+	}
+	if p.Side != nil {
+		g.Side = *p.Side
+		g.Away = g.Side == models.SideAway
+		locked = append(locked, "side")
+	}
+	if p.Disallowed != nil {
+		g.Disallowed = *p.Disallowed
+		locked = append(locked, "disallowed")
+	}
+	if p.HomeScore != nil {
+		g.HomeScore = *p.HomeScore
+		locked = append(locked, "home_score")
+	}
+	if p.AwayScore != nil {
+		g.AwayScore = *p.AwayScore
+		locked = append(locked, "away_score")
+	}
+	if p.MirrorsURL != nil {
+		g.MirrorsURL = *p.MirrorsURL
+		locked = append(locked, "mirrors_url")
+	}
+	if p.GameID != nil {
+		g.GameID = *p.GameID
+		locked = append(locked, "game_id")
+	}
+	return locked
+}
+
+// formatMinute writes a minute the way titles do, e.g. "90+3". An unknown
+// minute is "".
+func formatMinute(base, added int) string {
+	switch {
+	case base == 0:
+		return ""
+	case added == 0:
+		return strconv.Itoa(base)
+	}
+	return strconv.Itoa(base) + "+" + strconv.Itoa(added)
+}
+
+// GamePatch is a manual correction of a game. MergeInto moves every goal to
+// that game and deletes this one; it can't be combined with other fields.
+type GamePatch struct {
+	HomeTeam  *string `json:"home_team"`
+	AwayTeam  *string `json:"away_team"`
+	HomeScore *int    `json:"home_score"`
+	AwayScore *int    `json:"away_score"`
+	MergeInto *int    `json:"merge_into"`
+}
+
+func (p GamePatch) validate() error {
+	switch {
+	case p == GamePatch{}:
+		return fmt.Errorf("%w: nothing to change", ErrInvalid)
+	case p.MergeInto != nil && p != GamePatch{MergeInto: p.MergeInto}:
+		return fmt.Errorf("%w: merge_into can't be combined with other changes", ErrInvalid)
+	case p.HomeTeam != nil && names.TeamKey(*p.HomeTeam) == "",
+		p.AwayTeam != nil && names.TeamKey(*p.AwayTeam) == "":
+		return fmt.Errorf("%w: team names need a letter or digit", ErrInvalid)
+	case negative(p.HomeScore, p.AwayScore):
+		return fmt.Errorf("%w: scores can't be negative", ErrInvalid)
+	}
+	return nil
+}
+
+func negative(values ...*int) bool {
+	for _, v := range values {
+		if v != nil && *v < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lockFields adds fields to the locked_fields of the row in table with id.
+func lockFields(ctx context.Context, tx *sql.Tx, table string, id int, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`UPDATE `+table+` SET locked_fields = ARRAY(SELECT DISTINCT f FROM unnest(locked_fields || $2::text[]) f ORDER BY f)
+		 WHERE id = $1`,
+		id, fields,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s fields: %w", table, err)
+	}
+	return nil
+}
+
+// recountScore recounts a game's score from its goals, keeping a score that
+// was corrected by hand. The update is returned when the score changed.
+func recountScore(ctx context.Context, tx *sql.Tx, gameID int) (*models.ScoreUpdate, error) {
+	var oldHome, oldAway int
+	u := models.ScoreUpdate{GameID: gameID}
+	err := tx.QueryRowContext(ctx,
+		`WITH old AS (SELECT home_score, away_score FROM games WHERE id = $1)
+		 UPDATE games SET
+		   home_score = CASE WHEN 'home_score' = ANY(locked_fields) THEN home_score
+		     ELSE (SELECT COALESCE(MAX(home_score), 0) FROM goals WHERE game_id = $1 AND NOT disallowed) END,
+		   away_score = CASE WHEN 'away_score' = ANY(locked_fields) THEN away_score
+		     ELSE (SELECT COALESCE(MAX(away_score), 0) FROM goals WHERE game_id = $1 AND NOT disallowed) END
+		 WHERE id = $1
+		 RETURNING home_score, away_score, (SELECT home_score FROM old), (SELECT away_score FROM old)`,
+		gameID,
+	).Scan(&u.HomeScore, &u.AwayScore, &oldHome, &oldAway)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update game score: %w", err)
+	}
+	if u.HomeScore == oldHome && u.AwayScore == oldAway {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+// audit records a correction made by actor. A nil before or after is stored
+// as NULL.
+func audit(ctx context.Context, tx *sql.Tx, actor, action, entity string, id int, before, after any) error {
+	args := []any{actor, action, entity, id, nil, nil}
+	for i, v := range []any{before, after} {
+		if v == nil {
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		args[4+i] = string(b)
+	}
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO audit_log (actor, action, entity, entity_id, before, after) VALUES ($1, $2, $3, $4, $5, $6)",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// loadGame reads a game with all of its goals, or ErrNotFound.
+func loadGame(ctx context.Context, q queryer, id int, lock bool) (models.Game, error) {
+	query := "SELECT " + gameColumns + " FROM games WHERE id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var g models.Game
+	err := scanGame(q.QueryRowContext(ctx, query, id), &g)
+	if err == sql.ErrNoRows {
+		return g, ErrNotFound
+	}
+	if err != nil {
+		return g, fmt.Errorf("failed to query game: %w", err)
+	}
+	games := []models.Game{g}
+	if err := attachGoals(ctx, q, games, GamesFilter{}); err != nil {
+		return g, fmt.Errorf("failed to query goals: %w", err)
+	}
+	return games[0], nil
+}
+
+// loadGoal reads a goal with its game's teams and its mirrors, or ErrNotFound.
+func loadGoal(ctx context.Context, q queryer, id int, lock bool) (models.Goal, error) {
+	query := "SELECT " + goalColumns + " FROM goals WHERE id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var gl models.Goal
+	err := scanGoal(q.QueryRowContext(ctx, query, id), &gl)
+	if err == sql.ErrNoRows {
+		return gl, ErrNotFound
+	}
+	if err != nil {
+		return gl, fmt.Errorf("failed to query goal: %w", err)
+	}
+	err = q.QueryRowContext(ctx, "SELECT home_team, away_team FROM games WHERE id = $1", gl.GameID).Scan(&gl.HomeTeam, &gl.AwayTeam)
+	if err != nil {
+		return gl, fmt.Errorf("failed to query goal game: %w", err)
+	}
+	if err := attachMirrors(ctx, q, []*models.Goal{&gl}); err != nil {
+		return gl, err
+	}
+	return gl, nil
+}
+
+// PatchGoal corrects a goal on behalf of actor and records the change in the
+// audit log. Corrected fields are locked so that ingestion doesn't undo them.
+// Reassigning the goal to another game recounts the score of both games.
+func (s *Store) PatchGoal(ctx context.Context, actor string, id int, p GoalPatch) (models.Goal, error) {
+	if err := p.validate(); err != nil {
+		return models.Goal{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Goal{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadGoal(ctx, tx, id, true)
+	if err != nil {
+		return before, err
+	}
+	after := before
+	locked := p.apply(&after)
+
+	action := auditUpdate
+	if after.GameID != before.GameID {
+		action = auditReassign
+		if _, err := loadGame(ctx, tx, after.GameID, true); errors.Is(err, ErrNotFound) {
+			return models.Goal{}, fmt.Errorf("%w: game %d does not exist", ErrInvalid, after.GameID)
+		} else if err != nil {
+			return models.Goal{}, err
+		}
+	}
+	if p.Goalscorer != nil {
+		var homeID, awayID int
+		err := tx.QueryRowContext(ctx, "SELECT home_team_id, away_team_id FROM games WHERE id = $1", after.GameID).Scan(&homeID, &awayID)
+		if err != nil {
+			return models.Goal{}, fmt.Errorf("failed to query game teams: %w", err)
+		}
+		if after.PlayerID, after.Goalscorer, err = resolvePlayer(ctx, tx, after.Goalscorer, homeID, awayID); err != nil {
+			return models.Goal{}, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE goals SET game_id = $2, goalscorer = $3, player_id = NULLIF($4, 0), minute = $5, minute_base = $6,
+		   minute_added = $7, goal_type = $8, side = $9, away = $10, disallowed = $11, home_score = $12,
+		   away_score = $13, mirrors_url = $14
+		 WHERE id = $1`,
+		id, after.GameID, after.Goalscorer, after.PlayerID, after.Minute, after.MinuteBase,
+		after.MinuteAdded, after.Type, after.Side, after.Away, after.Disallowed, after.HomeScore,
+		after.AwayScore, after.MirrorsURL,
+	)
+	if err != nil {
+		return models.Goal{}, fmt.Errorf("failed to update goal: %w", err)
+	}
+	if err := lockFields(ctx, tx, "goals", id, locked); err != nil {
+		return models.Goal{}, err
+	}
+
+	var scores []*models.ScoreUpdate
+	for _, gameID := range []int{before.GameID, after.GameID} {
+		u, err := recountScore(ctx, tx, gameID)
+		if err != nil {
+			return models.Goal{}, err
+		}
+		scores = append(scores, u)
+		if after.GameID == before.GameID {
+			break
+		}
+	}
+
+	if after, err = loadGoal(ctx, tx, id, false); err != nil {
+		return after, err
+	}
+	if err := audit(ctx, tx, actor, action, "goal", id, before, after); err != nil {
+		return models.Goal{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Goal{}, fmt.Errorf("failed to commit correction: %w", err)
+	}
+
+	correction := models.Correction{Entity: "goal", EntityID: id, Action: action, GameIDs: []int{before.GameID}}
+	if after.GameID != before.GameID {
+		correction.GameIDs = append(correction.GameIDs, after.GameID)
+	}
+	s.publish(events.TypeCorrection, correction)
+	for _, u := range scores {
+		if u != nil {
+			s.publish(events.TypeScore, *u)
+		}
+	}
+	return after, nil
+}
+
+// PatchGame corrects a game on behalf of actor, or merges it into another, and
+// records the change in the audit log. Corrected teams and scores are locked
+// so that ingestion doesn't undo them. A merge returns the surviving game.
+func (s *Store) PatchGame(ctx context.Context, actor string, id int, p GamePatch) (models.Game, error) {
+	if err := p.validate(); err != nil {
+		return models.Game{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Game{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadGame(ctx, tx, id, true)
+	if err != nil {
+		return before, err
+	}
+
+	var after models.Game
+	var score *models.ScoreUpdate
+	correction := models.Correction{Entity: "game", EntityID: id, Action: auditUpdate, GameIDs: []int{id}}
+	if p.MergeInto != nil {
+		after, score, err = mergeGame(ctx, tx, actor, before, *p.MergeInto)
+		correction.Action = auditMerge
+		correction.GameIDs = append(correction.GameIDs, after.ID)
+	} else {
+		after, score, err = correctGame(ctx, tx, actor, before, p)
+	}
+	if err != nil {
+		return models.Game{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Game{}, fmt.Errorf("failed to commit correction: %w", err)
+	}
+	s.publish(events.TypeCorrection, correction)
+	if score != nil {
+		s.publish(events.TypeScore, *score)
+	}
+	return after, nil
+}
+
+func correctGame(ctx context.Context, tx *sql.Tx, actor string, before models.Game, p GamePatch) (models.Game, *models.ScoreUpdate, error) {
+	var locked []string
+	for _, side := range []struct {
+		typed  *string
+		column string
+	}{{p.HomeTeam, "home"}, {p.AwayTeam, "away"}} {
+		if side.typed == nil {
+			continue
+		}
+		team, err := resolveTeam(ctx, tx, *side.typed)
+		if err != nil {
+			return models.Game{}, nil, err
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE games SET "+side.column+"_team = $2, "+side.column+"_team_id = $3 WHERE id = $1",
+			before.ID, team.Name, team.ID)
+		if err != nil {
+			return models.Game{}, nil, fmt.Errorf("failed to update game team: %w", err)
+		}
+		locked = append(locked, side.column+"_team")
+	}
+	if p.HomeScore != nil {
+		locked = append(locked, "home_score")
+	}
+	if p.AwayScore != nil {
+		locked = append(locked, "away_score")
+	}
+	_, err := tx.ExecContext(ctx,
+		"UPDATE games SET home_score = COALESCE($2, home_score), away_score = COALESCE($3, away_score) WHERE id = $1",
+		before.ID, p.HomeScore, p.AwayScore)
+	if err != nil {
+		return models.Game{}, nil, fmt.Errorf("failed to update game score: %w", err)
+	}
+	if err := lockFields(ctx, tx, "games", before.ID, locked); err != nil {
+		return models.Game{}, nil, err
+	}
+
+	after, err := loadGame(ctx, tx, before.ID, false)
+	if err != nil {
+		return after, nil, err
+	}
+	if err := audit(ctx, tx, actor, auditUpdate, "game", before.ID, before, after); err != nil {
+		return after, nil, err
+	}
+
+	var score *models.ScoreUpdate
+	if after.HomeScore != before.HomeScore || after.AwayScore != before.AwayScore {
+		score = &models.ScoreUpdate{GameID: after.ID, HomeScore: after.HomeScore, AwayScore: after.AwayScore}
+	}
+	return after, score, nil
+}
+
+// mergeGame moves the goals of game into the game with id into, turned round
+// when game has the teams the other way round, deletes game and recounts the
+// score of the game merged into. A redirect from game's teams
+// and kick-off to the game merged into keeps ingestion from recreating game.
+func mergeGame(ctx context.Context, tx *sql.Tx, actor string, game models.Game, into int) (models.Game, *models.ScoreUpdate, error) {
+	if into == game.ID {
+		return models.Game{}, nil, fmt.Errorf("%w: can't merge a game into itself", ErrInvalid)
+	}
+	target, err := loadGame(ctx, tx, into, true)
+	if errors.Is(err, ErrNotFound) {
+		return models.Game{}, nil, fmt.Errorf("%w: game %d does not exist", ErrInvalid, into)
+	} else if err != nil {
+		return models.Game{}, nil, err
+	}
+
+	// A duplicate stored the other way round brings its goals over with home
+	// and away turned to the order of the game merged into
+	reversed := game.HomeTeamID != game.AwayTeamID &&
+		game.HomeTeamID == target.AwayTeamID && game.AwayTeamID == target.HomeTeamID
+	_, err = tx.ExecContext(ctx,
+		`UPDATE goals SET game_id = $2,
+		   home_score = CASE WHEN $3 THEN away_score ELSE home_score END,
+		   away_score = CASE WHEN $3 THEN home_score ELSE away_score END,
+		   side = CASE WHEN NOT $3 THEN side WHEN side = 'home' THEN 'away' WHEN side = 'away' THEN 'home' ELSE side END,
+		   away = CASE WHEN $3 THEN side = 'home' ELSE away END
+		 WHERE game_id = $1`,
+		game.ID, into, reversed)
+	if err != nil {
+		return models.Game{}, nil, fmt.Errorf("failed to move goals: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE games SET started_at = LEAST(started_at, $2) WHERE id = $1", into, game.StartedAt)
+	if err != nil {
+		return models.Game{}, nil, fmt.Errorf("failed to update game: %w", err)
+	}
+	// Leave a redirect so that ingestion finds the game merged into when more
+	// goals come in under the merged game's teams, carrying along the
+	// redirects that pointed at the merged game
+	_, err = tx.ExecContext(ctx,
+		"UPDATE game_redirects SET game_id = $2, swapped = swapped <> $3 WHERE game_id = $1", game.ID, into, reversed)
+	if err != nil {
+		return models.Game{}, nil, fmt.Errorf("failed to move game redirects: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO game_redirects (home_team_id, away_team_id, started_at, game_id, swapped) VALUES ($1, $2, $3, $4, $5)",
+		game.HomeTeamID, game.AwayTeamID, game.StartedAt, into, reversed)
+	if err != nil {
+		return models.Game{}, nil, fmt.Errorf("failed to insert game redirect: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM games WHERE id = $1", game.ID); err != nil {
+		return models.Game{}, nil, fmt.Errorf("failed to delete merged game: %w", err)
+	}
+	score, err := recountScore(ctx, tx, into)
+	if err != nil {
+		return models.Game{}, nil, err
+	}
+
+	after, err := loadGame(ctx, tx, into, false)
+	if err != nil {
+		return after, nil, err
+	}
+	if err := audit(ctx, tx, actor, auditMerge, "game", game.ID, game, after); err != nil {
+		return after, nil, err
+	}
+	return after, score, nil
+}
+
+// AuditLog returns the corrections made to one goal or game, newest first.
+func (s *Store) AuditLog(ctx context.Context, entity string, id int) ([]models.AuditEntry, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, actor, action, entity, entity_id, COALESCE(before, 'null'), COALESCE(after, 'null'), created_at
+		 FROM audit_log WHERE entity = $1 AND entity_id = $2
+		 ORDER BY created_at DESC, id DESC`,
+		entity, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Entity, &e.EntityID, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
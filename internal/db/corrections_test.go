@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"blooters/internal/events"
+	"blooters/internal/models"
+)
+
+func TestGoalPatchApply(t *testing.T) {
+	scorer, side, added := "bukayo saka (pen)", models.SideHome, 3
+	p := GoalPatch{Goalscorer: &scorer, Side: &side, MinuteAdded: &added}
+	if err := p.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+
+	g := models.Goal{Goalscorer: "Saka", Minute: "90", MinuteBase: 90, Side: models.SideUnknown}
+	locked := p.apply(&g)
+	if g.Goalscorer != "Bukayo Saka" || g.Minute != "90+3" || g.Side != models.SideHome || g.Away {
+		t.Errorf("apply() goal = %+v", g)
+	}
+	if want := []string{"goalscorer", "minute", "side"}; !reflect.DeepEqual(locked, want) {
+		t.Errorf("apply() locked = %v, want %v", locked, want)
+	}
+}
+
+func TestPatchValidate(t *testing.T) {
+	bad, neg, id := "header", -1, 3
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"empty goal patch", GoalPatch{}.validate()},
+		{"unknown goal type", GoalPatch{Type: &bad}.validate()},
+		{"unknown side", GoalPatch{Side: &bad}.validate()},
+		{"negative score", GoalPatch{HomeScore: &neg}.validate()},
+		{"empty game patch", GamePatch{}.validate()},
+		{"merge with changes", GamePatch{MergeInto: &id, HomeScore: &id}.validate()},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrInvalid) {
+			t.Errorf("%s: validate() error = %v, want ErrInvalid", tt.name, tt.err)
+		}
+	}
+
+	if err := (GamePatch{MergeInto: &id}).validate(); err != nil {
+		t.Errorf("merge: validate() error = %v", err)
+	}
+}
+
+func TestMergeGameRedirect(t *testing.T) {
+	s := openTestStore(t)
+	s.events = events.NewBroker(16)
+	ch, _ := s.events.Subscribe(0)
+	ctx := context.Background()
+
+	kickoff := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	goal := func(home, away, url string, homeScore int, at time.Time) models.Goal {
+		return models.Goal{
+			HomeTeam:   home,
+			AwayTeam:   away,
+			Goalscorer: "Bukayo Saka",
+			Url:        "https://streamable.com/" + url,
+			HomeScore:  homeScore,
+			PostedAt:   at,
+		}
+	}
+	// The same match under two spellings that aren't aliases of each other
+	res, err := s.StoreGoals(ctx, []models.Goal{
+		goal("Arsenal", "Chelsea", "first", 1, kickoff),
+		goal("The Gunners", "The Blues", "second", 2, kickoff.Add(10*time.Minute)),
+	})
+	if err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+	if len(res.Games) != 2 {
+		t.Fatalf("StoreGoals() stored %d games, want 2", len(res.Games))
+	}
+	into, dup := res.Games[0].GameID, res.Games[1].GameID
+	if res.Games[0].HomeTeam != "Arsenal" {
+		into, dup = dup, into
+	}
+
+	merged, err := s.PatchGame(ctx, "admin", dup, GamePatch{MergeInto: &into})
+	if err != nil {
+		t.Fatalf("PatchGame(merge) error = %v", err)
+	}
+	if merged.ID != into || len(merged.Goals) != 2 {
+		t.Fatalf("PatchGame(merge) = game %d with %d goals, want game %d with 2", merged.ID, len(merged.Goals), into)
+	}
+
+	// The next goal under the merged spelling goes to the game merged into
+	res, err = s.StoreGoals(ctx, []models.Goal{goal("The Gunners", "The Blues", "third", 3, kickoff.Add(20*time.Minute))})
+	if err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+	if gr := res.Games[0]; gr.GameID != into || gr.Created {
+		t.Errorf("StoreGoals() after merge stored into game %d (created %v), want game %d", gr.GameID, gr.Created, into)
+	}
+	// And so does one that has the teams the other way round
+	reversed := goal("The Blues", "The Gunners", "fourth", 0, kickoff.Add(30*time.Minute))
+	reversed.AwayScore, reversed.Side, reversed.Away = 4, models.SideAway, true
+	res, err = s.StoreGoals(ctx, []models.Goal{reversed})
+	if err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+	if gr := res.Games[0]; gr.GameID != into || gr.Created || gr.HomeTeam != "Arsenal" || gr.HomeScore != 4 {
+		t.Errorf("StoreGoals(reversed) after merge = game %d (created %v) %s %d-%d, want game %d Arsenal 4-0",
+			gr.GameID, gr.Created, gr.HomeTeam, gr.HomeScore, gr.AwayScore, into)
+	}
+
+	var corrections int
+	for len(ch) > 0 {
+		if ev := <-ch; ev.Type == events.TypeCorrection {
+			c := ev.Data.(models.Correction)
+			if c.Entity != "game" || c.EntityID != dup || c.Action != auditMerge {
+				t.Errorf("correction event = %+v, want a merge of game %d", c, dup)
+			}
+			corrections++
+		}
+	}
+	if corrections != 1 {
+		t.Errorf("got %d correction events, want 1", corrections)
+	}
+}
+
+func TestMergeReversedGame(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	kickoff := time.Now().Add(-12 * time.Hour).UTC().Truncate(time.Second)
+	goal := func(home, away, url string, homeScore, awayScore int, side string, at time.Time) models.Goal {
+		return models.Goal{
+			HomeTeam:   home,
+			AwayTeam:   away,
+			Goalscorer: "Cole Palmer",
+			Url:        "https://streamable.com/" + url,
+			HomeScore:  homeScore,
+			AwayScore:  awayScore,
+			Side:       side,
+			Away:       side == models.SideAway,
+			PostedAt:   at,
+		}
+	}
+	// Far enough apart not to be joined when stored
+	into, err := s.StoreGoals(ctx, []models.Goal{goal("Chelsea", "Arsenal", "first", 1, 0, models.SideHome, kickoff)})
+	if err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+	dup, err := s.StoreGoals(ctx, []models.Goal{goal("Arsenal", "Chelsea", "second", 0, 2, models.SideAway, kickoff.Add(2*matchWindow))})
+	if err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+	intoID, dupID := into.Games[0].GameID, dup.Games[0].GameID
+	if intoID == dupID {
+		t.Fatalf("reversed duplicate joined game %d", intoID)
+	}
+
+	merged, err := s.PatchGame(ctx, "admin", dupID, GamePatch{MergeInto: &intoID})
+	if err != nil {
+		t.Fatalf("PatchGame(merge) error = %v", err)
+	}
+	if merged.HomeTeam != "Chelsea" || merged.HomeScore != 2 || merged.AwayScore != 0 {
+		t.Errorf("merged game = %s %d-%d %s, want Chelsea 2-0 Arsenal", merged.HomeTeam, merged.HomeScore, merged.AwayScore, merged.AwayTeam)
+	}
+	for _, gl := range merged.Goals {
+		if gl.Url == "https://streamable.com/second" && (gl.HomeScore != 2 || gl.AwayScore != 0 || gl.Side != models.SideHome || gl.Away) {
+			t.Errorf("moved goal = %d-%d side %s away %v, want 2-0 side home away false", gl.HomeScore, gl.AwayScore, gl.Side, gl.Away)
+		}
+	}
+	// A later goal of the merged game, titled as it was, follows the redirect
+	// and is turned round too
+	res, err := s.StoreGoals(ctx, []models.Goal{goal("Arsenal", "Chelsea", "third", 0, 3, models.SideAway, kickoff.Add(2*matchWindow+10*time.Minute))})
+	if err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+	if gr := res.Games[0]; gr.GameID != intoID || gr.Created || gr.HomeTeam != "Chelsea" || gr.HomeScore != 3 || gr.AwayScore != 0 {
+		t.Errorf("StoreGoals() after merge = game %d (created %v) %s %d-%d, want game %d Chelsea 3-0",
+			gr.GameID, gr.Created, gr.HomeTeam, gr.HomeScore, gr.AwayScore, intoID)
+	}
+}
@@ -3,53 +3,128 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
+	"blooters/internal/events"
 	"blooters/internal/models"
+	"blooters/internal/names"
 )
 
-var DB *sql.DB
+// Config holds the connection settings for a Store.
+type Config struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// AutoMigrate applies pending schema migrations and the team alias seed
+	// when the store is opened.
+	AutoMigrate bool
+	// Schema, when set, is created if missing and used as the search_path, so
+	// several isolated stores can share one database (e.g. in tests).
+	Schema string
+}
+
+// ConfigFromEnv reads the DB_* environment variables.
+func ConfigFromEnv() Config {
+	return Config{
+		Host:            getEnv("DB_HOST", "localhost"),
+		Port:            getEnv("DB_PORT", "5432"),
+		User:            getEnv("DB_USER", "postgres"),
+		Password:        getEnv("DB_PASSWORD", "postgres"),
+		Name:            getEnv("DB_NAME", "blooters"),
+		MaxOpenConns:    10,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 5 * time.Minute,
+		AutoMigrate:     getEnv("DB_AUTO_MIGRATE", "true") != "false",
+	}
+}
 
-// Init connects to Postgres using environment variables. It returns an error on failure.
-func Init() error {
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	pass := getEnv("DB_PASSWORD", "postgres")
-	name := getEnv("DB_NAME", "blooters")
+func (c Config) DSN() string {
+	u := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   c.Host + ":" + c.Port,
+		Path:   c.Name,
+	}
+	if c.Schema != "" {
+		u.RawQuery = url.Values{"search_path": {c.Schema}}.Encode()
+	}
+	return u.String()
+}
 
-	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", user, pass, host, port, name)
+// Store is the Postgres-backed storage for games and goals. Every method takes
+// a context so request cancellation reaches the database.
+type Store struct {
+	db     *sql.DB
+	events *events.Broker
+}
 
-	db, err := sql.Open("pgx", dsn)
+// Open connects to Postgres and, if cfg.AutoMigrate is set, brings the schema
+// up to date. Changes made through the store are published to broker, which
+// may be nil when nobody listens.
+func Open(ctx context.Context, cfg Config, broker *events.Broker) (*Store, error) {
+	db, err := sql.Open("pgx", cfg.DSN())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
 
 	// ping with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	if err := db.PingContext(ctx); err != nil {
+	if err := db.PingContext(pingCtx); err != nil {
 		_ = db.Close()
-		return err
+		return nil, err
 	}
 
-	DB = db
-	return nil
+	if cfg.Schema != "" {
+		if _, err := db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{cfg.Schema}.Sanitize()); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	s := &Store{db: db, events: broker}
+	if cfg.AutoMigrate {
+		if err := s.Migrate(ctx); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		if err := s.SeedTeams(ctx); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
 }
 
-func Close() error {
-	if DB != nil {
-		return DB.Close()
+func (s *Store) publish(eventType string, data any) {
+	if s.events != nil {
+		s.events.Publish(eventType, data)
 	}
-	return nil
 }
 
 func getEnv(k, def string) string {
@@ -59,199 +134,718 @@ func getEnv(k, def string) string {
 	return def
 }
 
-func GetGames() ([]models.Game, error) {
-	if DB == nil {
-		return nil, fmt.Errorf("database not initialized")
+// Orders for GamesFilter.Sort, by kick-off.
+const (
+	SortNewest = "newest"
+	SortOldest = "oldest"
+)
+
+// GamesFilter narrows GetGames down. The zero value matches everything.
+type GamesFilter struct {
+	// GoalType keeps only goals of that type (a models.GoalType* constant).
+	GoalType string
+	// Disallowed, when set, keeps only goals that were (or weren't) ruled out.
+	Disallowed *bool
+	// Player keeps only goals scored by that player, under any known spelling.
+	Player string
+	// Team keeps only games the team played, under any known spelling.
+	Team string
+	// TeamID keeps only games the team with that ID played.
+	TeamID int
+	// From and To bound when games kicked off, To excluded. Zero is unbounded.
+	From, To time.Time
+	// MinGoals keeps only games with at least that many goals on the scoreboard.
+	MinGoals int
+	// Sort is SortNewest, the default, or SortOldest.
+	Sort string
+	// Limit caps the games returned, 0 for no limit.
+	Limit int
+	// After continues from the last game of a previous page.
+	After *GamesCursor
+}
+
+// goalFilter is the SQL condition on goals for f, with its arguments starting
+// at placeholder $n.
+func (f GamesFilter) goalFilter(n int) (string, []any) {
+	var player string
+	if f.Player != "" {
+		player = names.PlayerKey(f.Player)
 	}
+	return fmt.Sprintf("($%d = '' OR goal_type = $%d) AND ($%d::bool IS NULL OR disallowed = $%d)"+
+			" AND ($%d = '' OR player_id IN (SELECT player_id FROM player_aliases WHERE key = $%d))",
+			n, n, n+1, n+1, n+2, n+2),
+		[]any{f.GoalType, f.Disallowed, player}
+}
+
+func (f GamesFilter) filtersGoals() bool {
+	return f.GoalType != "" || f.Disallowed != nil || f.Player != ""
+}
+
+// GamesCursor is where a page of GetGames ends. Clients get it as an opaque
+// string, see String and ParseGamesCursor.
+type GamesCursor struct {
+	Sort      string    `json:"s"`
+	StartedAt time.Time `json:"t"`
+	ID        int       `json:"i"`
+}
+
+func (c GamesCursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
 
-	rows, err := DB.Query("SELECT id, home_team, away_team, home_score, away_score, timestamp FROM games ORDER BY timestamp DESC")
+// ParseGamesCursor decodes a cursor made by GamesCursor.String.
+func ParseGamesCursor(s string) (GamesCursor, error) {
+	var c GamesCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
-		return nil, err
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID <= 0 || c.StartedAt.IsZero() ||
+		(c.Sort != SortNewest && c.Sort != SortOldest) {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	return c, nil
+}
+
+// GamesPage is a page of GetGames. Next is empty on the last page.
+type GamesPage struct {
+	Games []models.Game
+	Next  string
+}
+
+// goalColumns are the goal columns read by scanGoal.
+const goalColumns = `id, game_id, description, goalscorer, COALESCE(player_id, 0), minute, minute_base, minute_added,
+	goal_type, disallowed, url, reddit_url, mirrors_url, away, side, home_score, away_score, posted_at, locked_fields`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGoal(row scanner, gl *models.Goal) error {
+	return row.Scan(&gl.ID, &gl.GameID, &gl.Description, &gl.Goalscorer, &gl.PlayerID, &gl.Minute, &gl.MinuteBase, &gl.MinuteAdded,
+		&gl.Type, &gl.Disallowed, &gl.Url, &gl.RedditURL, &gl.MirrorsURL, &gl.Away, &gl.Side, &gl.HomeScore, &gl.AwayScore, &gl.PostedAt,
+		typeMap.SQLScanner(&gl.LockedFields))
+}
+
+// gameColumns are the game columns read by scanGame.
+const gameColumns = `id, match_id, home_team, away_team, home_team_id, away_team_id, home_score, away_score, started_at, timestamp, locked_fields`
+
+func scanGame(row scanner, g *models.Game) error {
+	return row.Scan(&g.ID, &g.MatchID, &g.HomeTeam, &g.AwayTeam, &g.HomeTeamID, &g.AwayTeamID, &g.HomeScore, &g.AwayScore, &g.StartedAt, &g.Timestamp,
+		typeMap.SQLScanner(&g.LockedFields))
+}
+
+// GetGames returns a page of the games matching f, in f.Sort order, with their
+// matching goals. When f filters goals, games without a matching goal are left
+// out. It runs two queries regardless of the number of games: one for the
+// games and one for all of their goals, stitched together here.
+func (s *Store) GetGames(ctx context.Context, f GamesFilter) (GamesPage, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.filtersGoals() {
+		cond, condArgs := f.goalFilter(len(args) + 1)
+		args = append(args, condArgs...)
+		conds = append(conds, "EXISTS (SELECT 1 FROM goals WHERE game_id = games.id AND "+cond+")")
+	}
+	if f.Team != "" {
+		team := arg(names.TeamKey(f.Team))
+		conds = append(conds, fmt.Sprintf(
+			"(home_team_id = (SELECT team_id FROM team_aliases WHERE key = %s) OR away_team_id = (SELECT team_id FROM team_aliases WHERE key = %s))",
+			team, team))
+	}
+	if f.TeamID != 0 {
+		team := arg(f.TeamID)
+		conds = append(conds, fmt.Sprintf("(home_team_id = %s OR away_team_id = %s)", team, team))
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "started_at >= "+arg(f.From))
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "started_at < "+arg(f.To))
+	}
+	if f.MinGoals > 0 {
+		conds = append(conds, "home_score + away_score >= "+arg(f.MinGoals))
+	}
+
+	sortBy := f.Sort
+	if sortBy == "" {
+		sortBy = SortNewest
+	}
+	order, past := "DESC", "<"
+	if sortBy == SortOldest {
+		order, past = "ASC", ">"
+	}
+	if f.After != nil {
+		conds = append(conds, fmt.Sprintf("(started_at, id) %s (%s, %s)", past, arg(f.After.StartedAt), arg(f.After.ID)))
+	}
+
+	q := "SELECT " + gameColumns + " FROM games"
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	q += fmt.Sprintf(" ORDER BY started_at %s, id %s", order, order)
+	if f.Limit > 0 {
+		// One more to tell whether there is a next page
+		q += " LIMIT " + arg(f.Limit+1)
+	}
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return GamesPage{}, err
 	}
 	defer rows.Close()
 
-	var games []models.Game
+	var page GamesPage
 	for rows.Next() {
 		var g models.Game
-		var homeTeam, awayTeam string
-		var homeScore, awayScore int
-		var ts time.Time
-		if err := rows.Scan(&g.ID, &homeTeam, &awayTeam, &homeScore, &awayScore, &ts); err != nil {
-			return nil, err
-		}
-		g.HomeTeam = homeTeam
-		g.AwayTeam = awayTeam
-		g.HomeScore = homeScore
-		g.AwayScore = awayScore
-		g.Timestamp = ts
-
-		g.Goals, err = loadGoalsForGame(g.ID, g.HomeTeam, g.AwayTeam)
-		if err != nil {
-			return nil, err
+		if err := scanGame(rows, &g); err != nil {
+			return GamesPage{}, err
 		}
+		page.Games = append(page.Games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return GamesPage{}, err
+	}
+	rows.Close()
 
-		games = append(games, g)
+	if f.Limit > 0 && len(page.Games) > f.Limit {
+		page.Games = page.Games[:f.Limit]
+		last := page.Games[len(page.Games)-1]
+		page.Next = GamesCursor{Sort: sortBy, StartedAt: last.StartedAt, ID: last.ID}.String()
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if err := attachGoals(ctx, s.db, page.Games, f); err != nil {
+		return GamesPage{}, err
 	}
+	return page, nil
+}
+
+// Game returns one game with all its goals, or ErrNotFound.
+func (s *Store) Game(ctx context.Context, id int) (models.Game, error) {
+	return loadGame(ctx, s.db, id, false)
+}
 
-	return games, nil
+// Goal returns one goal with its game's teams and its mirrors, or ErrNotFound.
+func (s *Store) Goal(ctx context.Context, id int) (models.Goal, error) {
+	return loadGoal(ctx, s.db, id, false)
 }
 
-func loadGoalsForGame(gameID int, homeTeam, awayTeam string) ([]models.Goal, error) {
-	q := `SELECT id, description, goalscorer, minute, url, reddit_url, mirrors, away, home_score, away_score FROM goals WHERE game_id=$1 ORDER BY id`
-	rows, err := DB.Query(q, gameID)
+// attachGoals loads the goals of all the given games matching f, and their
+// mirrors, in one query each.
+func attachGoals(ctx context.Context, q queryer, games []models.Game, f GamesFilter) error {
+	if len(games) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(games))
+	byID := make(map[int]*models.Game, len(games))
+	for i := range games {
+		ids[i] = games[i].ID
+		byID[games[i].ID] = &games[i]
+	}
+
+	cond, args := f.goalFilter(2)
+	rows, err := q.QueryContext(ctx,
+		"SELECT "+goalColumns+" FROM goals WHERE game_id = ANY($1::int[]) AND "+cond+" ORDER BY game_id, id",
+		append([]any{ids}, args...)...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var goals []models.Goal
 	for rows.Next() {
 		var gl models.Goal
-		var hs, as int
-		if err := rows.Scan(&gl.ID, &gl.Description, &gl.Goalscorer, &gl.Minute, &gl.Url, &gl.RedditURL, &gl.Mirrors, &gl.Away, &hs, &as); err != nil {
-			return nil, err
+		if err := scanGoal(rows, &gl); err != nil {
+			return err
 		}
-		gl.GameID = gameID
-		gl.HomeTeam = homeTeam
-		gl.AwayTeam = awayTeam
-		gl.HomeScore = hs
-		gl.AwayScore = as
-		goals = append(goals, gl)
+		g := byID[gl.GameID]
+		gl.HomeTeam = g.HomeTeam
+		gl.AwayTeam = g.AwayTeam
+		g.Goals = append(g.Goals, gl)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return err
 	}
-	return goals, nil
+	rows.Close()
+
+	var goals []*models.Goal
+	for i := range games {
+		for j := range games[i].Goals {
+			goals = append(goals, &games[i].Goals[j])
+		}
+	}
+	return attachMirrors(ctx, q, goals)
 }
 
-// StoreGoals stores goals from r/soccer into the database, creating games as needed
-func StoreGoals(goals []models.Goal) error {
-	if DB == nil {
-		return fmt.Errorf("database not initialized")
+// matchWindow is how far apart two goal posts can be and still belong to the
+// same match, measured from the first goal posted.
+const matchWindow = 4 * time.Hour
+
+// matchID builds the stable identifier of a game from the team slugs, e.g.
+// 2026-10-18-arsenal-chelsea.
+func matchID(startedAt time.Time, homeSlug, awaySlug string) string {
+	return startedAt.UTC().Format("2006-01-02") + "-" + homeSlug + "-" + awaySlug
+}
+
+// score is a game score as told by a goal post.
+type score struct {
+	Home, Away int
+}
+
+// inferSide tells which side scored to reach s from the scores already known
+// for the game: the one side that is a goal up on a previous score. It is
+// models.SideUnknown when no previous score fits or two disagree.
+func inferSide(known []score, s score) string {
+	side := models.SideUnknown
+	if s.Home+s.Away == 1 {
+		known = append(known, score{})
+	}
+	for _, k := range known {
+		var got string
+		switch k {
+		case score{s.Home - 1, s.Away}:
+			got = models.SideHome
+		case score{s.Home, s.Away - 1}:
+			got = models.SideAway
+		default:
+			continue
+		}
+		if side != models.SideUnknown && side != got {
+			return models.SideUnknown
+		}
+		side = got
 	}
+	return side
+}
+
+// GameResult describes what StoreGoals changed for one game.
+type GameResult struct {
+	GameID       int
+	HomeTeam     string
+	AwayTeam     string
+	Created      bool // the game row was created by this call
+	ScoreChanged bool
+	HomeScore    int
+	AwayScore    int
+	Inserted     []models.Goal // new goals, with ID and GameID set
+	Updated      []models.Goal // existing goals whose mirrors comment was filled in
+	Skipped      []models.Goal // goals already stored with nothing to update
+}
 
-	// Group goals by game (home_team, away_team)
-	gameMap := make(map[string]models.Game)
+// StoreResult is the outcome of a StoreGoals call, one entry per game.
+type StoreResult struct {
+	Games []GameResult
+}
+
+// Totals sums the goal counts over all games.
+func (r StoreResult) Totals() (inserted, updated, skipped int) {
+	for _, g := range r.Games {
+		inserted += len(g.Inserted)
+		updated += len(g.Updated)
+		skipped += len(g.Skipped)
+	}
+	return inserted, updated, skipped
+}
+
+// StoreGoals stores goals from r/soccer into the database, creating games as
+// needed. The whole batch is applied in one transaction: either every game
+// and goal is written or nothing is. Events are published after the commit.
+func (s *Store) StoreGoals(ctx context.Context, goals []models.Goal) (StoreResult, error) {
+	if len(goals) == 0 {
+		return StoreResult{}, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return StoreResult{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := storeGoals(ctx, tx, goals)
+	if err != nil {
+		return StoreResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return StoreResult{}, fmt.Errorf("failed to commit goals: %w", err)
+	}
+	s.publishStored(result)
+	return result, nil
+}
+
+// storeGoals groups goals by game and stores each game in tx. The caller
+// commits and then publishes the result.
+func storeGoals(ctx context.Context, tx *sql.Tx, goals []models.Goal) (StoreResult, error) {
+	var result StoreResult
+
+	// Group goals by game (normalized home and away team), dropping repeated
+	// URLs since one upsert can't touch the same row twice
+	now := time.Now()
+	gameMap := make(map[string]*models.Game)
+	var keys []string
+	seen := make(map[string]bool)
 	for _, goal := range goals {
-		key := goal.HomeTeam + " vs " + goal.AwayTeam
+		if seen[goal.Url] {
+			continue
+		}
+		seen[goal.Url] = true
+		if goal.PostedAt.IsZero() {
+			goal.PostedAt = now
+		}
+
+		key := names.TeamKey(goal.HomeTeam) + " vs " + names.TeamKey(goal.AwayTeam)
 		if game, exists := gameMap[key]; exists {
 			game.Goals = append(game.Goals, goal)
-			gameMap[key] = game
+			if goal.PostedAt.Before(game.StartedAt) {
+				game.StartedAt = goal.PostedAt
+			}
 		} else {
-			gameMap[key] = models.Game{
+			gameMap[key] = &models.Game{
 				HomeTeam:  goal.HomeTeam,
 				AwayTeam:  goal.AwayTeam,
 				HomeScore: goal.HomeScore,
 				AwayScore: goal.AwayScore,
 				Goals:     []models.Goal{goal},
-				Timestamp: time.Now(),
+				StartedAt: goal.PostedAt,
+				Timestamp: now,
 			}
+			keys = append(keys, key)
 		}
 	}
+	// A fixed order keeps row locks consistent between concurrent writers
+	sort.Strings(keys)
 
-	// Store each game and its goals
-	for _, game := range gameMap {
-		// Check if game already exists
-		var existingGameID int
-		err := DB.QueryRow(
-			"SELECT id FROM games WHERE home_team=$1 AND away_team=$2",
-			game.HomeTeam, game.AwayTeam,
-		).Scan(&existingGameID)
-
-		var gameID int
-		if err == sql.ErrNoRows {
-			// Insert new game
-			err := DB.QueryRow(
-				"INSERT INTO games (home_team, away_team, home_score, away_score, timestamp) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-				game.HomeTeam, game.AwayTeam, game.HomeScore, game.AwayScore, game.Timestamp,
-			).Scan(&gameID)
-			if err != nil {
-				return fmt.Errorf("failed to insert game: %w", err)
-			}
-		} else if err != nil {
-			return fmt.Errorf("failed to query game: %w", err)
-		} else {
-			gameID = existingGameID
-		}
-
-		// Insert goals for this game
-		for _, goal := range game.Goals {
-			// Try to insert, skip silently if duplicate
-			_, err := DB.Exec(
-				`INSERT INTO goals 
-				 (game_id, description, goalscorer, minute, url, reddit_url, mirrors, away, home_score, away_score)
-				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-				 ON CONFLICT (url) DO UPDATE SET
-				 mirrors = CASE WHEN goals.mirrors = '' THEN EXCLUDED.mirrors ELSE goals.mirrors END`,
-				gameID, goal.Description, goal.Goalscorer, goal.Minute, goal.Url, goal.RedditURL, goal.Mirrors, goal.Away, goal.HomeScore, goal.AwayScore,
-			)
-			if err != nil {
-				fmt.Printf("Warning: failed to insert goal: %v\n", err)
-			}
+	for _, key := range keys {
+		gr, err := storeGame(ctx, tx, gameMap[key])
+		if err != nil {
+			return StoreResult{}, err
+		}
+		result.Games = append(result.Games, gr)
+	}
+	return result, nil
+}
+
+// publishStored publishes the events for goals stored by a committed
+// storeGoals.
+func (s *Store) publishStored(result StoreResult) {
+	for _, gr := range result.Games {
+		for _, goal := range gr.Inserted {
+			s.publish(events.TypeGoal, goal)
+		}
+		for _, goal := range gr.Updated {
+			s.publish(events.TypeMirrors, models.MirrorsUpdate{GoalID: goal.ID, MirrorsURL: goal.MirrorsURL, Mirrors: goal.Mirrors})
+		}
+		if gr.ScoreChanged {
+			s.publish(events.TypeScore, models.ScoreUpdate{
+				GameID:    gr.GameID,
+				HomeScore: gr.HomeScore,
+				AwayScore: gr.AwayScore,
+			})
 		}
+	}
+}
 
-		//Update the score as the goals go in:
-		err = DB.QueryRow(
-			"SELECT COALESCE(MAX(home_score), 0), COALESCE(MAX(away_score), 0) FROM goals WHERE game_id = $1",
-			gameID,
-		).Scan(&game.HomeScore, &game.AwayScore)
+// storeGame finds or creates the game row and upserts its goals in one statement.
+func storeGame(ctx context.Context, tx *sql.Tx, game *models.Game) (GameResult, error) {
+	gr := GameResult{HomeTeam: game.HomeTeam, AwayTeam: game.AwayTeam}
+
+	homeKey, awayKey := names.TeamKey(game.HomeTeam), names.TeamKey(game.AwayTeam)
+	home, err := resolveTeam(ctx, tx, game.HomeTeam)
+	if err != nil {
+		return gr, err
+	}
+	away, err := resolveTeam(ctx, tx, game.AwayTeam)
+	if err != nil {
+		return gr, err
+	}
+	gr.HomeTeam, gr.AwayTeam = home.Name, away.Name
+	for i := range game.Goals {
+		g := &game.Goals[i]
+		g.HomeTeam, g.AwayTeam = home.Name, away.Name
+		if g.Goalscorer == "" {
+			continue
+		}
+		g.PlayerID, g.Goalscorer, err = resolvePlayer(ctx, tx, g.Goalscorer, home.ID, away.ID)
 		if err != nil {
-			return fmt.Errorf("failed to update game score: %w", err)
+			return gr, err
 		}
+	}
 
-		_, err = DB.Exec(
-			"UPDATE games SET home_score = $1, away_score = $2 WHERE id = $3",
-			game.HomeScore, game.AwayScore, gameID,
-		)
+	// Check if game already exists: a game of the same two teams in the same
+	// match window. A title that has them the other way round joins it too,
+	// with its scores and sides turned to the game's order
+	var oldHome, oldAway int
+	var gameHome, gameAway string
+	var swapped bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, home_team, away_team, home_score, away_score, home_team_id <> $1 FROM games
+		 WHERE ((home_team_id = $1 AND away_team_id = $2) OR (home_team_id = $2 AND away_team_id = $1))
+		   AND started_at BETWEEN $3::timestamptz - make_interval(secs => $4) AND $3::timestamptz + make_interval(secs => $4)
+		 ORDER BY (home_team_id = $1) DESC, abs(extract(epoch FROM started_at - $3::timestamptz))
+		 LIMIT 1
+		 FOR UPDATE`,
+		home.ID, away.ID, game.StartedAt, matchWindow.Seconds(),
+	).Scan(&gr.GameID, &gameHome, &gameAway, &oldHome, &oldAway, &swapped)
+	if err == sql.ErrNoRows {
+		// A game merged away by hand redirects to the one it was merged into,
+		// whichever way round the title has the teams
+		err = tx.QueryRowContext(ctx,
+			`SELECT g.id, g.home_team, g.away_team, g.home_score, g.away_score, (r.home_team_id <> $1) <> r.swapped
+			 FROM game_redirects r JOIN games g ON g.id = r.game_id
+			 WHERE ((r.home_team_id = $1 AND r.away_team_id = $2) OR (r.home_team_id = $2 AND r.away_team_id = $1))
+			   AND r.started_at BETWEEN $3::timestamptz - make_interval(secs => $4) AND $3::timestamptz + make_interval(secs => $4)
+			 ORDER BY (r.home_team_id = $1) DESC, abs(extract(epoch FROM r.started_at - $3::timestamptz))
+			 LIMIT 1
+			 FOR UPDATE OF g`,
+			home.ID, away.ID, game.StartedAt, matchWindow.Seconds(),
+		).Scan(&gr.GameID, &gameHome, &gameAway, &oldHome, &oldAway, &swapped)
+	}
+	if err == nil {
+		if swapped {
+			swapSides(game)
+		}
+		gr.HomeTeam, gr.AwayTeam = gameHome, gameAway
+		for i := range game.Goals {
+			game.Goals[i].HomeTeam, game.Goals[i].AwayTeam = gameHome, gameAway
+		}
+	}
+	if err == sql.ErrNoRows {
+		// Insert new game. Should another writer have created the same match
+		// meanwhile, the conflict update locks and returns its row instead
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO games (match_id, home_team, away_team, home_key, away_key, home_team_id, away_team_id, home_score, away_score, started_at, timestamp)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			 ON CONFLICT (match_id) DO UPDATE SET match_id = EXCLUDED.match_id
+			 RETURNING id, home_score, away_score, (xmax = 0)`,
+			matchID(game.StartedAt, home.Slug, away.Slug), home.Name, away.Name, homeKey, awayKey, home.ID, away.ID,
+			game.HomeScore, game.AwayScore, game.StartedAt, game.Timestamp,
+		).Scan(&gr.GameID, &oldHome, &oldAway, &gr.Created)
 		if err != nil {
-			return fmt.Errorf("failed to update game: %w", err)
+			return gr, fmt.Errorf("failed to insert game: %w", err)
+		}
+	} else if err != nil {
+		return gr, fmt.Errorf("failed to query game: %w", err)
+	}
+
+	if err := fillSides(ctx, tx, gr.GameID, game.Goals); err != nil {
+		return gr, err
+	}
+
+	n := len(game.Goals)
+	descriptions, scorers, minutes := make([]string, n), make([]string, n), make([]string, n)
+	urls, redditURLs, mirrors := make([]string, n), make([]string, n), make([]string, n)
+	aways, sides := make([]bool, n), make([]string, n)
+	homeScores, awayScores := make([]int, n), make([]int, n)
+	postedAts := make([]time.Time, n)
+	playerIDs := make([]int, n)
+	types, minuteBases, minuteAddeds := make([]string, n), make([]int, n), make([]int, n)
+	disallowed := make([]bool, n)
+	for i, g := range game.Goals {
+		descriptions[i], scorers[i], minutes[i] = g.Description, g.Goalscorer, g.Minute
+		urls[i], redditURLs[i], mirrors[i] = g.Url, g.RedditURL, g.MirrorsURL
+		aways[i], sides[i] = g.Away, g.Side
+		homeScores[i], awayScores[i] = g.HomeScore, g.AwayScore
+		postedAts[i] = g.PostedAt
+		playerIDs[i] = g.PlayerID
+		types[i], minuteBases[i], minuteAddeds[i] = g.Type, g.MinuteBase, g.MinuteAdded
+		disallowed[i] = g.Disallowed
+		if types[i] == "" {
+			types[i] = models.GoalTypeOpenPlay
+		}
+	}
+
+	// Insert all goals at once. Existing goals only get their mirrors comment
+	// filled in, unless it was corrected by hand; rows the WHERE clause leaves
+	// alone are not returned, which is how skipped goals are told apart. xmax
+	// is 0 only for fresh inserts.
+	rows, err := tx.QueryContext(ctx,
+		`INSERT INTO goals
+		 (game_id, description, goalscorer, minute, url, reddit_url, mirrors_url, away, home_score, away_score, posted_at, player_id,
+		  goal_type, minute_base, minute_added, disallowed, side)
+		 SELECT $1, t.description, t.goalscorer, t.minute, t.url, t.reddit_url, t.mirrors_url, t.away,
+		   t.home_score, t.away_score, t.posted_at, NULLIF(t.player_id, 0),
+		   t.goal_type, t.minute_base, t.minute_added, t.disallowed, t.side
+		 FROM unnest(
+		   $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::bool[], $9::int[], $10::int[], $11::timestamptz[], $12::int[],
+		   $13::text[], $14::int[], $15::int[], $16::bool[], $17::text[]
+		 ) AS t(description, goalscorer, minute, url, reddit_url, mirrors_url, away, home_score, away_score, posted_at, player_id,
+		   goal_type, minute_base, minute_added, disallowed, side)
+		 ON CONFLICT (url) DO UPDATE SET mirrors_url = EXCLUDED.mirrors_url
+		 WHERE goals.mirrors_url = '' AND EXCLUDED.mirrors_url <> '' AND NOT 'mirrors_url' = ANY(goals.locked_fields)
+		 RETURNING id, url, (xmax = 0)`,
+		gr.GameID, descriptions, scorers, minutes, urls, redditURLs, mirrors, aways, homeScores, awayScores, postedAts, playerIDs,
+		types, minuteBases, minuteAddeds, disallowed, sides,
+	)
+	if err != nil {
+		return gr, fmt.Errorf("failed to insert goals: %w", err)
+	}
+	defer rows.Close()
+
+	type written struct {
+		id       int
+		inserted bool
+	}
+	changed := make(map[string]written)
+	for rows.Next() {
+		var url string
+		var w written
+		if err := rows.Scan(&w.id, &url, &w.inserted); err != nil {
+			return gr, fmt.Errorf("failed to scan inserted goal: %w", err)
+		}
+		changed[url] = w
+	}
+	if err := rows.Err(); err != nil {
+		return gr, fmt.Errorf("failed to insert goals: %w", err)
+	}
+	rows.Close()
+
+	for _, goal := range game.Goals {
+		w, ok := changed[goal.Url]
+		switch {
+		case !ok:
+			gr.Skipped = append(gr.Skipped, goal)
+		case w.inserted:
+			goal.ID = w.id
+			goal.GameID = gr.GameID
+			gr.Inserted = append(gr.Inserted, goal)
+		default:
+			goal.ID = w.id
+			gr.Updated = append(gr.Updated, goal)
+		}
+		if ok {
+			if _, err := insertMirrors(ctx, tx, w.id, goal.Mirrors); err != nil {
+				return gr, err
+			}
+		}
+	}
+
+	//Update the score as the goals go in, unless it was corrected by hand:
+	err = tx.QueryRowContext(ctx,
+		`UPDATE games SET
+		   home_score = CASE WHEN 'home_score' = ANY(locked_fields) THEN home_score
+		     ELSE (SELECT COALESCE(MAX(home_score), 0) FROM goals WHERE game_id = $1 AND NOT disallowed) END,
+		   away_score = CASE WHEN 'away_score' = ANY(locked_fields) THEN away_score
+		     ELSE (SELECT COALESCE(MAX(away_score), 0) FROM goals WHERE game_id = $1 AND NOT disallowed) END,
+		   started_at = LEAST(started_at, $2)
+		 WHERE id = $1
+		 RETURNING home_score, away_score`,
+		gr.GameID, game.StartedAt,
+	).Scan(&gr.HomeScore, &gr.AwayScore)
+	if err != nil {
+		return gr, fmt.Errorf("failed to update game score: %w", err)
+	}
+	gr.ScoreChanged = gr.HomeScore != oldHome || gr.AwayScore != oldAway
+
+	return gr, nil
+}
+
+// swapSides turns a game, and its goals, the other way round: home becomes
+// away and away home.
+func swapSides(game *models.Game) {
+	game.HomeTeam, game.AwayTeam = game.AwayTeam, game.HomeTeam
+	game.HomeScore, game.AwayScore = game.AwayScore, game.HomeScore
+	for i := range game.Goals {
+		g := &game.Goals[i]
+		g.HomeTeam, g.AwayTeam = g.AwayTeam, g.HomeTeam
+		g.HomeScore, g.AwayScore = g.AwayScore, g.HomeScore
+		switch g.Side {
+		case models.SideHome:
+			g.Side = models.SideAway
+		case models.SideAway:
+			g.Side = models.SideHome
+		}
+		g.Away = g.Side == models.SideAway
+	}
+}
+
+// fillSides settles the side of goals whose title didn't tell by diffing their
+// score against the scores already stored for the game and those in this
+// batch. Goals keep models.SideUnknown when that doesn't tell either.
+func fillSides(ctx context.Context, tx *sql.Tx, gameID int, goals []models.Goal) error {
+	var known []score
+	unknown := false
+	for i := range goals {
+		if goals[i].Side == "" {
+			goals[i].Side = models.SideUnknown
+		}
+		if goals[i].Side == models.SideUnknown {
+			unknown = true
 		}
+		if !goals[i].Disallowed {
+			known = append(known, score{goals[i].HomeScore, goals[i].AwayScore})
+		}
+	}
+	if !unknown {
+		return nil
 	}
 
+	rows, err := tx.QueryContext(ctx,
+		"SELECT DISTINCT home_score, away_score FROM goals WHERE game_id = $1 AND NOT disallowed", gameID)
+	if err != nil {
+		return fmt.Errorf("failed to query game scores: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k score
+		if err := rows.Scan(&k.Home, &k.Away); err != nil {
+			return fmt.Errorf("failed to scan game score: %w", err)
+		}
+		known = append(known, k)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query game scores: %w", err)
+	}
+
+	for i := range goals {
+		g := &goals[i]
+		if g.Side == models.SideUnknown {
+			g.Side = inferSide(known, score{g.HomeScore, g.AwayScore})
+			g.Away = g.Side == models.SideAway
+		}
+	}
 	return nil
 }
 
-func RemoveOldGoals() error {
-	if DB == nil {
-		return fmt.Errorf("database not initialized")
+func (s *Store) RemoveOldGoals(ctx context.Context) error {
+	// Session-level advisory locks belong to a connection, so hold one for the
+	// lock, the cleanup and the unlock
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
 	}
+	defer conn.Close()
 
 	// Use advisory lock to prevent concurrent cleanup
 	var locked bool
-	err := DB.QueryRow("SELECT pg_try_advisory_lock($1)", int64(1001)).Scan(&locked)
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", int64(1001)).Scan(&locked)
 	if err != nil {
 		return fmt.Errorf("failed to acquire advisory lock: %w", err)
 	}
 	if !locked {
 		return fmt.Errorf("cleanup already in progress")
 	}
-	defer DB.Exec("SELECT pg_advisory_unlock($1)", int64(1001))
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", int64(1001))
 
-	tx, err := DB.Begin()
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer tx.Rollback()
 
 	// Clean up games: keep only the 100 most recent
 	var gameCount int
-	err = tx.QueryRow("SELECT COUNT(*) FROM games").Scan(&gameCount)
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM games").Scan(&gameCount)
 	if err != nil {
 		return fmt.Errorf("failed to count games: %w", err)
 	}
 	if gameCount > 100 {
 		toDelete := gameCount - 100
-		_, err = tx.Exec(`
+		_, err = tx.ExecContext(ctx, `
 			DELETE FROM games
 			WHERE id IN (
 				SELECT id FROM games
@@ -264,5 +858,91 @@ func RemoveOldGoals() error {
 	}
 	// Goals are deleted automatically via CASCADE, no need to delete separately
 
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM parse_failures WHERE created_at < now() - make_interval(secs => $1)", failureRetention.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to delete old parse failures: %w", err)
+	}
+
+	if err := pruneChanges(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cleanup: %w", err)
+	}
+	if gameCount > 100 {
+		s.publish(events.TypeChange, models.Change{Reason: models.ChangeCleanup})
+	}
 	return nil
 }
+
+// GetCursor returns the persisted cursor for a goal source, or "" if it has none yet.
+func (s *Store) GetCursor(ctx context.Context, source string) (string, error) {
+	var cursor string
+	err := s.db.QueryRowContext(ctx, "SELECT cursor FROM source_cursors WHERE source = $1", source).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+// SetCursor persists the cursor for a goal source.
+func (s *Store) SetCursor(ctx context.Context, source, cursor string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO source_cursors (source, cursor, updated_at) VALUES ($1, $2, now())
+		 ON CONFLICT (source) DO UPDATE SET cursor = EXCLUDED.cursor, updated_at = EXCLUDED.updated_at`,
+		source, cursor,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	return nil
+}
+
+// queryGoals reads the goals matching cond, which may go on with ORDER BY and
+// LIMIT, with their game's teams and their mirrors.
+func queryGoals(ctx context.Context, q queryer, cond string, args ...any) ([]models.Goal, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT `+goalColumns+`, (SELECT home_team FROM games WHERE id = game_id), (SELECT away_team FROM games WHERE id = game_id)
+		 FROM goals WHERE `+cond, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		var gl models.Goal
+		if err := scanGoal(teamsScanner{rows, &gl}, &gl); err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, gl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query goals: %w", err)
+	}
+	rows.Close()
+
+	ptrs := make([]*models.Goal, len(goals))
+	for i := range goals {
+		ptrs[i] = &goals[i]
+	}
+	if err := attachMirrors(ctx, q, ptrs); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+// teamsScanner scans a row of goalColumns followed by the home and away team
+// of the goal's game.
+type teamsScanner struct {
+	row  scanner
+	goal *models.Goal
+}
+
+func (t teamsScanner) Scan(dest ...any) error {
+	return t.row.Scan(append(dest, &t.goal.HomeTeam, &t.goal.AwayTeam)...)
+}
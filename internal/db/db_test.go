@@ -0,0 +1,344 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"blooters/internal/events"
+	"blooters/internal/models"
+)
+
+// openTestStore connects to the Postgres described by the DB_* variables, in
+// a fresh schema that is dropped afterwards. Tests using it are skipped unless
+// BLOOTERS_TEST_DB=1, e.g.
+//
+//	docker compose up -d db && BLOOTERS_TEST_DB=1 go test -bench . ./internal/db
+func openTestStore(tb testing.TB) *Store {
+	tb.Helper()
+	if os.Getenv("BLOOTERS_TEST_DB") != "1" {
+		tb.Skip("set BLOOTERS_TEST_DB=1 to run against a local Postgres")
+	}
+
+	cfg := ConfigFromEnv()
+	cfg.AutoMigrate = true
+	cfg.Schema = fmt.Sprintf("test_%d", time.Now().UnixNano())
+
+	ctx := context.Background()
+	s, err := Open(ctx, cfg, nil)
+	if err != nil {
+		tb.Fatalf("Open() error = %v", err)
+	}
+	tb.Cleanup(func() {
+		s.db.ExecContext(ctx, "DROP SCHEMA "+pgx.Identifier{cfg.Schema}.Sanitize()+" CASCADE")
+		s.Close()
+	})
+	return s
+}
+
+// seedGames stores n games with goalsPerGame goals each.
+func seedGames(tb testing.TB, s *Store, n, goalsPerGame int) {
+	tb.Helper()
+	var goals []models.Goal
+	for g := 0; g < n; g++ {
+		for i := 1; i <= goalsPerGame; i++ {
+			goals = append(goals, models.Goal{
+				HomeTeam:    fmt.Sprintf("Home %d", g),
+				AwayTeam:    fmt.Sprintf("Away %d", g),
+				Description: fmt.Sprintf("Home %d [%d]-0 Away %d - Scorer %d", g, i, g, i),
+				Goalscorer:  fmt.Sprintf("Scorer %d", i),
+				Minute:      fmt.Sprint(i * 10),
+				Url:         fmt.Sprintf("https://streamable.com/%d-%d", g, i),
+				RedditURL:   fmt.Sprintf("https://www.reddit.com/r/soccer/comments/%d_%d", g, i),
+				HomeScore:   i,
+			})
+		}
+	}
+	if _, err := s.StoreGoals(context.Background(), goals); err != nil {
+		tb.Fatalf("StoreGoals() error = %v", err)
+	}
+}
+
+// getGamesNPlusOne is the previous GetGames implementation: one query for the
+// games and then one more per game. It is kept to compare against.
+func (s *Store) getGamesNPlusOne(ctx context.Context) ([]models.Game, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+gameColumns+" FROM games ORDER BY started_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []models.Game
+	for rows.Next() {
+		var g models.Game
+		if err := scanGame(rows, &g); err != nil {
+			return nil, err
+		}
+
+		goalRows, err := s.db.QueryContext(ctx, "SELECT "+goalColumns+" FROM goals WHERE game_id=$1 ORDER BY id", g.ID)
+		if err != nil {
+			return nil, err
+		}
+		for goalRows.Next() {
+			gl := models.Goal{HomeTeam: g.HomeTeam, AwayTeam: g.AwayTeam}
+			if err := scanGoal(goalRows, &gl); err != nil {
+				goalRows.Close()
+				return nil, err
+			}
+			if err := attachMirrors(ctx, s.db, []*models.Goal{&gl}); err != nil {
+				goalRows.Close()
+				return nil, err
+			}
+			g.Goals = append(g.Goals, gl)
+		}
+		goalRows.Close()
+		if err := goalRows.Err(); err != nil {
+			return nil, err
+		}
+
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+func TestGetGamesMatchesPerGameQueries(t *testing.T) {
+	s := openTestStore(t)
+	seedGames(t, s, 20, 3)
+
+	ctx := context.Background()
+	page, err := s.GetGames(ctx, GamesFilter{})
+	if err != nil {
+		t.Fatalf("GetGames() error = %v", err)
+	}
+	got := page.Games
+	want, err := s.getGamesNPlusOne(ctx)
+	if err != nil {
+		t.Fatalf("getGamesNPlusOne() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetGames() differs from the per-game query result\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func BenchmarkGetGames(b *testing.B) {
+	s := openTestStore(b)
+	seedGames(b, s, 100, 5)
+	ctx := context.Background()
+
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.GetGames(ctx, GamesFilter{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("n+1", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.getGamesNPlusOne(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestInferSide(t *testing.T) {
+	tests := []struct {
+		known []score
+		s     score
+		want  string
+	}{
+		{nil, score{1, 0}, models.SideHome},
+		{nil, score{0, 1}, models.SideAway},
+		{nil, score{2, 1}, models.SideUnknown},
+		{[]score{{1, 1}}, score{2, 1}, models.SideHome},
+		{[]score{{2, 0}}, score{2, 1}, models.SideAway},
+		{[]score{{1, 0}, {2, 1}}, score{2, 1}, models.SideUnknown},
+		{[]score{{1, 1}, {2, 0}}, score{2, 1}, models.SideUnknown},
+		{[]score{{2, 1}}, score{2, 1}, models.SideUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := inferSide(tt.known, tt.s); got != tt.want {
+			t.Errorf("inferSide(%v, %v) = %q, want %q", tt.known, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestGetGamesPages(t *testing.T) {
+	s := openTestStore(t)
+	seedGames(t, s, 7, 2)
+
+	ctx := context.Background()
+	for _, order := range []string{SortNewest, SortOldest} {
+		all, err := s.GetGames(ctx, GamesFilter{Sort: order})
+		if err != nil {
+			t.Fatalf("GetGames() error = %v", err)
+		}
+
+		var got []models.Game
+		f := GamesFilter{Sort: order, Limit: 3}
+		for {
+			page, err := s.GetGames(ctx, f)
+			if err != nil {
+				t.Fatalf("GetGames() error = %v", err)
+			}
+			got = append(got, page.Games...)
+			if page.Next == "" {
+				break
+			}
+			c, err := ParseGamesCursor(page.Next)
+			if err != nil {
+				t.Fatalf("ParseGamesCursor(%q) error = %v", page.Next, err)
+			}
+			f.After = &c
+		}
+		if !reflect.DeepEqual(got, all.Games) {
+			t.Errorf("%s: paged games differ from the full list\ngot:  %+v\nwant: %+v", order, got, all.Games)
+		}
+	}
+}
+
+func TestParseGamesCursor(t *testing.T) {
+	c := GamesCursor{Sort: SortOldest, StartedAt: time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC), ID: 42}
+	got, err := ParseGamesCursor(c.String())
+	if err != nil {
+		t.Fatalf("ParseGamesCursor(%q) error = %v", c.String(), err)
+	}
+	if !got.StartedAt.Equal(c.StartedAt) || got.ID != c.ID || got.Sort != c.Sort {
+		t.Errorf("ParseGamesCursor() = %+v, want %+v", got, c)
+	}
+
+	for _, s := range []string{"", "!!", "e30", GamesCursor{Sort: "sideways", StartedAt: c.StartedAt, ID: 1}.String()} {
+		if _, err := ParseGamesCursor(s); !errors.Is(err, ErrInvalid) {
+			t.Errorf("ParseGamesCursor(%q) error = %v, want ErrInvalid", s, err)
+		}
+	}
+}
+
+func TestGameAndGoalNotFound(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	if _, err := s.Game(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Game() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Goal(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Goal() error = %v, want ErrNotFound", err)
+	}
+	if _, _, err := s.PlayerGoals(ctx, "Nobody", 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PlayerGoals() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.TeamByName(ctx, "Nowhere FC"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TeamByName() error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestWritesPublishChange checks that writes without an event of their own
+// still tell subscribers, and so the response cache, that the games changed.
+func TestWritesPublishChange(t *testing.T) {
+	s := openTestStore(t)
+	seedGames(t, s, 101, 1)
+	s.events = events.NewBroker(16)
+	ch, _ := s.events.Subscribe(0)
+	ctx := context.Background()
+
+	expect := func(what, reason string) {
+		t.Helper()
+		select {
+		case ev := <-ch:
+			if c, ok := ev.Data.(models.Change); ev.Type != events.TypeChange || !ok || c.Reason != reason {
+				t.Errorf("%s published %s %+v, want a %q change", what, ev.Type, ev.Data, reason)
+			}
+		default:
+			t.Errorf("%s published nothing, want a %q change", what, reason)
+		}
+	}
+
+	page, err := s.GetGames(ctx, GamesFilter{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddTeamAlias(ctx, page.Games[0].HomeTeamID, "Another Spelling"); err != nil {
+		t.Fatalf("AddTeamAlias() error = %v", err)
+	}
+	expect("AddTeamAlias", models.ChangeTeams)
+
+	if err := s.RemoveOldGoals(ctx); err != nil {
+		t.Fatalf("RemoveOldGoals() error = %v", err)
+	}
+	expect("RemoveOldGoals", models.ChangeCleanup)
+}
+
+func TestSwapSides(t *testing.T) {
+	game := models.Game{
+		HomeTeam: "Chelsea", AwayTeam: "Arsenal", HomeScore: 0, AwayScore: 2,
+		Goals: []models.Goal{
+			{HomeTeam: "Chelsea", AwayTeam: "Arsenal", AwayScore: 1, Side: models.SideAway, Away: true},
+			{HomeTeam: "Chelsea", AwayTeam: "Arsenal", AwayScore: 2, Side: models.SideUnknown},
+		},
+	}
+	swapSides(&game)
+
+	want := models.Game{
+		HomeTeam: "Arsenal", AwayTeam: "Chelsea", HomeScore: 2, AwayScore: 0,
+		Goals: []models.Goal{
+			{HomeTeam: "Arsenal", AwayTeam: "Chelsea", HomeScore: 1, Side: models.SideHome},
+			{HomeTeam: "Arsenal", AwayTeam: "Chelsea", HomeScore: 2, Side: models.SideUnknown},
+		},
+	}
+	if !reflect.DeepEqual(game, want) {
+		t.Errorf("swapSides() = %+v, want %+v", game, want)
+	}
+}
+
+// TestStoreGoalsMatchesBothTeams checks that a goal only joins a game in the
+// match window when both teams match, in either order.
+func TestStoreGoalsMatchesBothTeams(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	kickoff := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	store := func(home, away, url string, homeScore, awayScore int, side string, after time.Duration) GameResult {
+		t.Helper()
+		res, err := s.StoreGoals(ctx, []models.Goal{{
+			HomeTeam:   home,
+			AwayTeam:   away,
+			Goalscorer: "Cole Palmer",
+			Url:        "https://streamable.com/" + url,
+			HomeScore:  homeScore,
+			AwayScore:  awayScore,
+			Side:       side,
+			Away:       side == models.SideAway,
+			PostedAt:   kickoff.Add(after),
+		}})
+		if err != nil {
+			t.Fatalf("StoreGoals(%s vs %s) error = %v", home, away, err)
+		}
+		return res.Games[0]
+	}
+
+	first := store("Chelsea", "Arsenal", "first", 1, 0, models.SideHome, 0)
+	if !first.Created {
+		t.Fatalf("first goal didn't create a game")
+	}
+	// One team in common isn't the same match
+	if other := store("Chelsea", "Tottenham", "other", 1, 0, models.SideHome, 10*time.Minute); other.GameID == first.GameID {
+		t.Errorf("Chelsea vs Tottenham joined Chelsea vs Arsenal (game %d)", first.GameID)
+	}
+	// The same teams the other way round are, with the score turned round
+	swapped := store("Arsenal", "Chelsea", "swapped", 0, 2, models.SideAway, 20*time.Minute)
+	if swapped.GameID != first.GameID || swapped.Created {
+		t.Fatalf("Arsenal vs Chelsea stored into game %d (created %v), want game %d", swapped.GameID, swapped.Created, first.GameID)
+	}
+	if swapped.HomeTeam != "Chelsea" || swapped.HomeScore != 2 || swapped.AwayScore != 0 {
+		t.Errorf("after the swapped goal = %s %d-%d, want Chelsea 2-0", swapped.HomeTeam, swapped.HomeScore, swapped.AwayScore)
+	}
+	goal := swapped.Inserted[0]
+	if goal.HomeScore != 2 || goal.AwayScore != 0 || goal.Side != models.SideHome {
+		t.Errorf("swapped goal = %d-%d %s, want 2-0 home", goal.HomeScore, goal.AwayScore, goal.Side)
+	}
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blooters/internal/models"
+)
+
+// failureRetention is how long rejected posts are kept for review.
+const failureRetention = 14 * 24 * time.Hour
+
+// ParseFailuresFilter narrows ListParseFailures. The zero value lists pending
+// failures of any reason.
+type ParseFailuresFilter struct {
+	Reason   string
+	Promoted bool // list promoted failures instead of pending ones
+	Limit    int  // at most this many, 0 for all
+}
+
+const failureColumns = `id, source, title, url, permalink, reason, posted_at, created_at, COALESCE(goal_id, 0), promoted_at`
+
+func scanFailure(row scanner, f *models.ParseFailure) error {
+	return row.Scan(&f.ID, &f.Source, &f.Title, &f.URL, &f.Permalink, &f.Reason, &f.PostedAt, &f.CreatedAt, &f.GoalID, &f.PromotedAt)
+}
+
+// StoreParseFailures quarantines rejected posts. A post seen again keeps its
+// row; only its reason is refreshed, and only while it is pending.
+func (s *Store) StoreParseFailures(ctx context.Context, failures []models.ParseFailure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	// One upsert can't touch the same row twice
+	seen := make(map[string]bool)
+	var sources, titles, urls, permalinks, reasons []string
+	var postedAts []time.Time
+	for _, f := range failures {
+		if seen[f.Permalink] {
+			continue
+		}
+		seen[f.Permalink] = true
+		sources = append(sources, f.Source)
+		titles = append(titles, f.Title)
+		urls = append(urls, f.URL)
+		permalinks = append(permalinks, f.Permalink)
+		reasons = append(reasons, f.Reason)
+		postedAts = append(postedAts, f.PostedAt)
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO parse_failures (source, title, url, permalink, reason, posted_at)
+		 SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::timestamptz[])
+		 ON CONFLICT (permalink) DO UPDATE SET reason = EXCLUDED.reason
+		 WHERE parse_failures.promoted_at IS NULL AND parse_failures.reason <> EXCLUDED.reason`,
+		sources, titles, urls, permalinks, reasons, postedAts,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store parse failures: %w", err)
+	}
+	return nil
+}
+
+// ListParseFailures returns the failures matching f, newest post first.
+func (s *Store) ListParseFailures(ctx context.Context, f ParseFailuresFilter) ([]models.ParseFailure, error) {
+	var limit any
+	if f.Limit > 0 {
+		limit = f.Limit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+failureColumns+` FROM parse_failures
+		 WHERE ($1 = '' OR reason = $1) AND (promoted_at IS NOT NULL) = $2
+		 ORDER BY posted_at DESC, id DESC
+		 LIMIT $3`,
+		f.Reason, f.Promoted, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query parse failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []models.ParseFailure{}
+	for rows.Next() {
+		var pf models.ParseFailure
+		if err := scanFailure(rows, &pf); err != nil {
+			return nil, err
+		}
+		failures = append(failures, pf)
+	}
+	return failures, rows.Err()
+}
+
+// ParseFailure returns one failure, or ErrNotFound.
+func (s *Store) ParseFailure(ctx context.Context, id int) (models.ParseFailure, error) {
+	var f models.ParseFailure
+	err := scanFailure(s.db.QueryRowContext(ctx, `SELECT `+failureColumns+` FROM parse_failures WHERE id = $1`, id), &f)
+	if err == sql.ErrNoRows {
+		return f, ErrNotFound
+	}
+	if err != nil {
+		return f, fmt.Errorf("failed to query parse failure: %w", err)
+	}
+	return f, nil
+}
+
+// SetParseFailureReason records why a pending failure still doesn't parse.
+func (s *Store) SetParseFailureReason(ctx context.Context, id int, reason string) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE parse_failures SET reason = $2 WHERE id = $1 AND promoted_at IS NULL", id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to update parse failure: %w", err)
+	}
+	return nil
+}
+
+// PromoteParseFailure stores goal, read from the failed post, and marks the
+// failure as promoted to it, in one transaction.
+func (s *Store) PromoteParseFailure(ctx context.Context, id int, goal models.Goal) (models.ParseFailure, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ParseFailure{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT true FROM parse_failures WHERE id = $1 FOR UPDATE", id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return models.ParseFailure{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ParseFailure{}, fmt.Errorf("failed to query parse failure: %w", err)
+	}
+
+	result, err := storeGoals(ctx, tx, []models.Goal{goal})
+	if err != nil {
+		return models.ParseFailure{}, err
+	}
+
+	var f models.ParseFailure
+	err = scanFailure(tx.QueryRowContext(ctx,
+		`UPDATE parse_failures SET
+		   goal_id = (SELECT id FROM goals WHERE url = $2),
+		   promoted_at = COALESCE(promoted_at, now())
+		 WHERE id = $1
+		 RETURNING `+failureColumns,
+		id, goal.Url,
+	), &f)
+	if err != nil {
+		return f, fmt.Errorf("failed to mark parse failure promoted: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.ParseFailure{}, fmt.Errorf("failed to commit promotion: %w", err)
+	}
+	s.publishStored(result)
+	return f, nil
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS goals;
+DROP TABLE IF EXISTS games;
@@ -1,3 +1,5 @@
+-- Baseline schema, formerly db/init.sql. Written to be a no-op on databases
+-- that were created from that file.
 CREATE TABLE IF NOT EXISTS games (
   id SERIAL PRIMARY KEY,
   home_team TEXT NOT NULL,
@@ -23,4 +25,9 @@ CREATE TABLE IF NOT EXISTS goals (
   away_score INT DEFAULT 0
 );
 
-ALTER TABLE goals ADD CONSTRAINT unique_goal_url UNIQUE (url);
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'unique_goal_url') THEN
+    ALTER TABLE goals ADD CONSTRAINT unique_goal_url UNIQUE (url);
+  END IF;
+END $$;
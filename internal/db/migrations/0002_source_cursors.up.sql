@@ -0,0 +1,6 @@
+-- Newest item each goal source has processed, so a restart can backfill the gap
+CREATE TABLE IF NOT EXISTS source_cursors (
+  source TEXT PRIMARY KEY,
+  cursor TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
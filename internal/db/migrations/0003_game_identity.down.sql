@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_games_away_key_started_at;
+DROP INDEX IF EXISTS idx_games_home_key_started_at;
+
+ALTER TABLE goals DROP COLUMN IF EXISTS posted_at;
+
+ALTER TABLE games
+  DROP CONSTRAINT IF EXISTS unique_game_match_id,
+  DROP COLUMN IF EXISTS match_id,
+  DROP COLUMN IF EXISTS home_key,
+  DROP COLUMN IF EXISTS away_key,
+  DROP COLUMN IF EXISTS started_at;
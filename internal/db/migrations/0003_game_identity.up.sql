@@ -0,0 +1,44 @@
+-- Games are identified by their teams plus the time the match was played, so
+-- a rematch later in the season gets its own row. home_key/away_key are the
+-- normalized team names (names.TeamKey) and started_at is when the first goal
+-- of the match was posted.
+ALTER TABLE games
+  ADD COLUMN match_id TEXT,
+  ADD COLUMN home_key TEXT,
+  ADD COLUMN away_key TEXT,
+  ADD COLUMN started_at TIMESTAMPTZ;
+
+ALTER TABLE goals ADD COLUMN posted_at TIMESTAMPTZ;
+
+-- Approximate the Go normalization for existing rows
+UPDATE games SET
+  home_key = trim(regexp_replace(lower(home_team), '[^a-z0-9]+', ' ', 'g')),
+  away_key = trim(regexp_replace(lower(away_team), '[^a-z0-9]+', ' ', 'g')),
+  started_at = timestamp;
+
+UPDATE games g SET match_id = m.match_id
+FROM (
+  SELECT id,
+    to_char(started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') || '-' ||
+    replace(home_key, ' ', '-') || '-' || replace(away_key, ' ', '-') ||
+    CASE WHEN row_number() OVER (PARTITION BY home_key, away_key, (started_at AT TIME ZONE 'UTC')::date ORDER BY id) > 1
+         THEN '-' || id ELSE '' END AS match_id
+  FROM games
+) m
+WHERE g.id = m.id;
+
+UPDATE goals SET posted_at = g.started_at FROM games g WHERE goals.game_id = g.id;
+
+ALTER TABLE games
+  ALTER COLUMN match_id SET NOT NULL,
+  ALTER COLUMN home_key SET NOT NULL,
+  ALTER COLUMN away_key SET NOT NULL,
+  ALTER COLUMN started_at SET NOT NULL,
+  ADD CONSTRAINT unique_game_match_id UNIQUE (match_id);
+
+ALTER TABLE goals
+  ALTER COLUMN posted_at SET DEFAULT now(),
+  ALTER COLUMN posted_at SET NOT NULL;
+
+CREATE INDEX idx_games_home_key_started_at ON games (home_key, started_at);
+CREATE INDEX idx_games_away_key_started_at ON games (away_key, started_at);
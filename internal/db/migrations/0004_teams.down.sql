@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_games_away_team_id_started_at;
+DROP INDEX IF EXISTS idx_games_home_team_id_started_at;
+
+ALTER TABLE games
+  DROP COLUMN IF EXISTS home_team_id,
+  DROP COLUMN IF EXISTS away_team_id;
+
+DROP TABLE IF EXISTS team_aliases;
+DROP TABLE IF EXISTS teams;
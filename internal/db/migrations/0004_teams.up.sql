@@ -0,0 +1,47 @@
+-- Teams are the canonical clubs; team_aliases maps every normalized spelling
+-- seen in titles (names.TeamKey) to one of them. source records who created
+-- the alias: 'auto' on first sight during ingestion, 'seed' from the embedded
+-- alias file and 'admin' through the API. Seeding never overrides admin rows.
+CREATE TABLE teams (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  slug TEXT NOT NULL,
+  CONSTRAINT unique_team_slug UNIQUE (slug)
+);
+
+CREATE TABLE team_aliases (
+  key TEXT PRIMARY KEY,
+  team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  source TEXT NOT NULL DEFAULT 'auto',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_team_aliases_team_id ON team_aliases (team_id);
+
+-- One team per spelling already stored, named after its latest use
+INSERT INTO teams (name, slug)
+SELECT DISTINCT ON (key) name, replace(key, ' ', '-')
+FROM (
+  SELECT home_key AS key, home_team AS name, timestamp FROM games
+  UNION ALL
+  SELECT away_key, away_team, timestamp FROM games
+) seen
+ORDER BY key, timestamp DESC;
+
+INSERT INTO team_aliases (key, team_id)
+SELECT replace(slug, '-', ' '), id FROM teams;
+
+ALTER TABLE games
+  ADD COLUMN home_team_id INT REFERENCES teams(id),
+  ADD COLUMN away_team_id INT REFERENCES teams(id);
+
+UPDATE games SET
+  home_team_id = (SELECT team_id FROM team_aliases WHERE key = games.home_key),
+  away_team_id = (SELECT team_id FROM team_aliases WHERE key = games.away_key);
+
+ALTER TABLE games
+  ALTER COLUMN home_team_id SET NOT NULL,
+  ALTER COLUMN away_team_id SET NOT NULL;
+
+CREATE INDEX idx_games_home_team_id_started_at ON games (home_team_id, started_at);
+CREATE INDEX idx_games_away_team_id_started_at ON games (away_team_id, started_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_goals_player_id;
+ALTER TABLE goals DROP COLUMN IF EXISTS player_id;
+
+DROP TABLE IF EXISTS player_aliases;
+DROP TABLE IF EXISTS players;
@@ -0,0 +1,41 @@
+-- Players are the canonical goalscorers. player_aliases maps every normalized
+-- spelling (names.PlayerKey) to a player; unlike team aliases a spelling may
+-- belong to several players (there is more than one "Silva"), so lookups
+-- disambiguate by the teams involved.
+CREATE TABLE players (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE player_aliases (
+  key TEXT NOT NULL,
+  player_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+  PRIMARY KEY (key, player_id)
+);
+
+CREATE INDEX idx_player_aliases_player_id ON player_aliases (player_id);
+
+ALTER TABLE goals ADD COLUMN player_id INT REFERENCES players(id) ON DELETE SET NULL;
+CREATE INDEX idx_goals_player_id ON goals (player_id);
+
+-- One player per spelling already stored, approximating the Go normalization
+CREATE TEMPORARY TABLE seen_scorers ON COMMIT DROP AS
+SELECT DISTINCT ON (key) key, goalscorer AS name
+FROM (
+  SELECT trim(regexp_replace(lower(goalscorer), '[^[:alnum:]]+', ' ', 'g')) AS key, goalscorer, id
+  FROM goals
+  WHERE goalscorer <> ''
+) g
+WHERE key <> ''
+ORDER BY key, id DESC;
+
+ALTER TABLE seen_scorers ADD COLUMN player_id INT;
+UPDATE seen_scorers SET player_id = nextval(pg_get_serial_sequence('players', 'id'));
+
+INSERT INTO players (id, name) SELECT player_id, name FROM seen_scorers;
+INSERT INTO player_aliases (key, player_id) SELECT key, player_id FROM seen_scorers;
+
+UPDATE goals SET player_id = s.player_id
+FROM seen_scorers s
+WHERE s.key = trim(regexp_replace(lower(goals.goalscorer), '[^[:alnum:]]+', ' ', 'g'));
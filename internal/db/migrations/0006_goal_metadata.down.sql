@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_goals_goal_type;
+
+ALTER TABLE goals
+  DROP CONSTRAINT IF EXISTS goals_goal_type_check,
+  DROP COLUMN IF EXISTS goal_type,
+  DROP COLUMN IF EXISTS minute_base,
+  DROP COLUMN IF EXISTS minute_added,
+  DROP COLUMN IF EXISTS disallowed;
@@ -0,0 +1,23 @@
+-- Typed goal details parsed from the title. minute_base is 0 when the minute
+-- is unknown; disallowed goals are kept but don't count towards the score.
+ALTER TABLE goals
+  ADD COLUMN goal_type TEXT NOT NULL DEFAULT 'open_play',
+  ADD COLUMN minute_base INT NOT NULL DEFAULT 0,
+  ADD COLUMN minute_added INT NOT NULL DEFAULT 0,
+  ADD COLUMN disallowed BOOLEAN NOT NULL DEFAULT false,
+  ADD CONSTRAINT goals_goal_type_check
+    CHECK (goal_type IN ('open_play', 'penalty', 'own_goal', 'free_kick'));
+
+-- Backfill from the stored titles and minutes, like reddit.ParseGoalFromTitle
+UPDATE goals SET
+  goal_type = CASE
+    WHEN description ~* '\(\s*o\.?g\.?\s*\)|\mown[ -]goal\M|\mog\M' THEN 'own_goal'
+    WHEN description ~* '\(\s*p(en(alty)?)?\.?\s*\)|\mpen(alty)?\M' THEN 'penalty'
+    WHEN description ~* '\(\s*fk\s*\)|\mfree[ -]kick\M' THEN 'free_kick'
+    ELSE 'open_play'
+  END,
+  minute_base = COALESCE(substring(minute FROM '^(\d+)')::int, 0),
+  minute_added = COALESCE(substring(minute FROM '^\d+\s*\+\s*(\d+)')::int, 0),
+  disallowed = description ~* '\m(disallowed|ruled out|chalked off)\M|\mvar\M.*\m(no goal|overturned)\M|\m(no goal|overturned)\M.*\mvar\M';
+
+CREATE INDEX idx_goals_goal_type ON goals (goal_type);
@@ -0,0 +1,3 @@
+ALTER TABLE goals
+  DROP CONSTRAINT IF EXISTS goals_side_check,
+  DROP COLUMN IF EXISTS side;
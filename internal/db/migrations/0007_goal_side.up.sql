@@ -0,0 +1,36 @@
+-- Which side scored, from the [n] marker in the title or by diffing against
+-- the game's previous score. 'unknown' when neither tells; away is only true
+-- for a known away goal.
+ALTER TABLE goals
+  ADD COLUMN side TEXT NOT NULL DEFAULT 'unknown',
+  ADD CONSTRAINT goals_side_check CHECK (side IN ('home', 'away', 'unknown'));
+
+-- The marker, unless both scores carry one
+UPDATE goals SET side = CASE
+    WHEN description ~ '\[\d+\]\s*[-–—]\s*\[\d+\]' THEN 'unknown'
+    WHEN description ~ '\[[1-9]\d*\]\s*[-–—]\s*\d' THEN 'home'
+    WHEN description ~ '\d\s*[-–—]\s*\[[1-9]\d*\]' THEN 'away'
+    ELSE 'unknown'
+  END;
+
+-- The remaining goals, where exactly one side is a goal up on a previous score
+UPDATE goals g SET side = d.side
+FROM (
+  SELECT g.id, CASE WHEN count(DISTINCT s.side) = 1 THEN min(s.side) END AS side
+  FROM goals g
+  JOIN LATERAL (
+    SELECT CASE WHEN p.home_score = g.home_score - 1 THEN 'home' ELSE 'away' END AS side
+    FROM goals p
+    WHERE p.game_id = g.game_id AND NOT p.disallowed
+      AND ((p.home_score = g.home_score - 1 AND p.away_score = g.away_score)
+        OR (p.home_score = g.home_score AND p.away_score = g.away_score - 1))
+    UNION ALL
+    SELECT CASE WHEN g.home_score = 1 THEN 'home' ELSE 'away' END
+    WHERE g.home_score + g.away_score = 1
+  ) s ON true
+  WHERE g.side = 'unknown'
+  GROUP BY g.id
+) d
+WHERE d.id = g.id AND d.side IS NOT NULL;
+
+UPDATE goals SET away = (side = 'away');
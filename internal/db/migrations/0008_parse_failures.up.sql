@@ -0,0 +1,18 @@
+-- Posts a source rejected, e.g. a title the parser couldn't read. A failure is
+-- promoted once a goal was stored for it.
+CREATE TABLE parse_failures (
+  id SERIAL PRIMARY KEY,
+  source TEXT NOT NULL,
+  title TEXT NOT NULL,
+  url TEXT NOT NULL,
+  permalink TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  posted_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  goal_id INT REFERENCES goals(id) ON DELETE SET NULL,
+  promoted_at TIMESTAMPTZ,
+  CONSTRAINT unique_parse_failure_permalink UNIQUE (permalink)
+);
+
+CREATE INDEX idx_parse_failures_pending ON parse_failures (posted_at DESC) WHERE promoted_at IS NULL;
+CREATE INDEX idx_parse_failures_created_at ON parse_failures (created_at);
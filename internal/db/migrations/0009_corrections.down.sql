@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE games DROP COLUMN IF EXISTS locked_fields;
+ALTER TABLE goals DROP COLUMN IF EXISTS locked_fields;
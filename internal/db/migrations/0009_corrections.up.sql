@@ -0,0 +1,17 @@
+-- Fields corrected by hand, which ingestion leaves alone from then on
+ALTER TABLE goals ADD COLUMN locked_fields TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE games ADD COLUMN locked_fields TEXT[] NOT NULL DEFAULT '{}';
+
+-- Every manual correction: who changed what, with the row before and after
+CREATE TABLE audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL,
+  entity TEXT NOT NULL,
+  entity_id INT NOT NULL,
+  before JSONB,
+  after JSONB,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity, entity_id, created_at DESC);
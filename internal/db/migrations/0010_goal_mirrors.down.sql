@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS goal_mirrors;
+
+UPDATE goals SET locked_fields = array_replace(locked_fields, 'mirrors_url', 'mirrors')
+WHERE 'mirrors_url' = ANY(locked_fields);
+ALTER TABLE goals RENAME COLUMN mirrors_url TO mirrors;
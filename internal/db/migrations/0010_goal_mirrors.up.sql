@@ -0,0 +1,15 @@
+-- goals.mirrors held the permalink of the mirrors comment, not the mirrors
+ALTER TABLE goals RENAME COLUMN mirrors TO mirrors_url;
+UPDATE goals SET locked_fields = array_replace(locked_fields, 'mirrors', 'mirrors_url')
+WHERE 'mirrors' = ANY(locked_fields);
+
+-- The links found in the mirrors comment, in the order they were posted
+CREATE TABLE goal_mirrors (
+  id SERIAL PRIMARY KEY,
+  goal_id INT NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+  host TEXT NOT NULL,
+  label TEXT NOT NULL DEFAULT '',
+  url TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT unique_goal_mirror UNIQUE (goal_id, url)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_goals_mirrors_next_poll_at;
+
+ALTER TABLE goals
+  DROP COLUMN IF EXISTS mirrors_next_poll_at,
+  DROP COLUMN IF EXISTS mirrors_polls;
@@ -0,0 +1,10 @@
+-- Mirrors keep being posted after a goal, so each goal's mirrors comment is
+-- polled a few times. mirrors_next_poll_at is NULL once polling is over.
+ALTER TABLE goals
+  ADD COLUMN mirrors_polls INT NOT NULL DEFAULT 0,
+  ADD COLUMN mirrors_next_poll_at TIMESTAMPTZ DEFAULT now();
+
+-- Goals that already have their mirrors comment were polled once
+UPDATE goals SET mirrors_polls = 1, mirrors_next_poll_at = NULL WHERE mirrors_url <> '';
+
+CREATE INDEX idx_goals_mirrors_next_poll_at ON goals (mirrors_next_poll_at) WHERE mirrors_next_poll_at IS NOT NULL;
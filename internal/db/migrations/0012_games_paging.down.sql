@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_goals_game_id;
+DROP INDEX IF EXISTS idx_games_started_at_id;
@@ -0,0 +1,4 @@
+-- GET /api/games pages through games by kick-off, with id as tie-breaker, and
+-- loads the goals of each page by game.
+CREATE INDEX idx_games_started_at_id ON games (started_at, id);
+CREATE INDEX idx_goals_game_id ON goals (game_id);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS goal_mirrors_changes ON goal_mirrors;
+DROP TRIGGER IF EXISTS goals_changes ON goals;
+DROP TRIGGER IF EXISTS games_changes ON games;
+DROP FUNCTION IF EXISTS record_change();
+DROP TABLE IF EXISTS changes_floor;
+DROP TABLE IF EXISTS changes;
+DROP SEQUENCE IF EXISTS change_revision;
@@ -0,0 +1,69 @@
+-- The change feed behind GET /api/changes. Every write to a game or goal bumps
+-- its row here to the next revision, so a client that synced up to revision N
+-- only needs the rows above N. Deleted rows stay as tombstones until pruned;
+-- changes_floor is the highest revision pruned so far, and a client behind it
+-- has to sync from scratch.
+CREATE SEQUENCE change_revision;
+
+CREATE TABLE changes (
+  entity TEXT NOT NULL CHECK (entity IN ('game', 'goal')),
+  entity_id INT NOT NULL,
+  revision BIGINT NOT NULL,
+  deleted BOOLEAN NOT NULL DEFAULT false,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (entity, entity_id)
+);
+
+CREATE UNIQUE INDEX idx_changes_revision ON changes (revision);
+CREATE INDEX idx_changes_tombstones ON changes (changed_at) WHERE deleted;
+
+CREATE TABLE changes_floor (
+  revision BIGINT NOT NULL
+);
+INSERT INTO changes_floor VALUES (0);
+
+-- Records a change to the row, as the entity named by the trigger argument, or
+-- to the goal of a new mirror. Updates that only touch the mirrors polling
+-- bookkeeping don't count. Writers take a transaction-level lock before
+-- drawing a revision, so revisions become visible in order and a reader never
+-- skips one that commits late.
+CREATE FUNCTION record_change() RETURNS trigger AS $$
+DECLARE
+  changed_id INT;
+BEGIN
+  IF TG_OP = 'UPDATE' AND to_jsonb(OLD) - 'mirrors_polls' - 'mirrors_next_poll_at'
+                          = to_jsonb(NEW) - 'mirrors_polls' - 'mirrors_next_poll_at' THEN
+    RETURN NULL;
+  END IF;
+
+  IF TG_TABLE_NAME = 'goal_mirrors' THEN
+    changed_id := NEW.goal_id;
+  ELSIF TG_OP = 'DELETE' THEN
+    changed_id := OLD.id;
+  ELSE
+    changed_id := NEW.id;
+  END IF;
+
+  PERFORM pg_advisory_xact_lock(1003);
+  INSERT INTO changes (entity, entity_id, revision, deleted, changed_at)
+  VALUES (TG_ARGV[0], changed_id, nextval('change_revision'), TG_OP = 'DELETE', now())
+  ON CONFLICT (entity, entity_id) DO UPDATE SET
+    revision = EXCLUDED.revision,
+    deleted = EXCLUDED.deleted,
+    changed_at = EXCLUDED.changed_at;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER games_changes AFTER INSERT OR UPDATE OR DELETE ON games
+  FOR EACH ROW EXECUTE FUNCTION record_change('game');
+CREATE TRIGGER goals_changes AFTER INSERT OR UPDATE OR DELETE ON goals
+  FOR EACH ROW EXECUTE FUNCTION record_change('goal');
+CREATE TRIGGER goal_mirrors_changes AFTER INSERT ON goal_mirrors
+  FOR EACH ROW EXECUTE FUNCTION record_change('goal');
+
+-- What is stored already is the first revision of everything
+INSERT INTO changes (entity, entity_id, revision)
+SELECT 'game', id, nextval('change_revision') FROM games ORDER BY id;
+INSERT INTO changes (entity, entity_id, revision)
+SELECT 'goal', id, nextval('change_revision') FROM goals ORDER BY id;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_goals_search;
+DROP TRIGGER IF EXISTS games_search ON games;
+DROP TRIGGER IF EXISTS goals_search ON goals;
+DROP FUNCTION IF EXISTS games_search_update();
+DROP FUNCTION IF EXISTS goals_search_update();
+ALTER TABLE goals DROP COLUMN IF EXISTS search;
+DROP FUNCTION IF EXISTS goal_search(TEXT, TEXT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS search_text(TEXT);
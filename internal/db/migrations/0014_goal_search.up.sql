@@ -0,0 +1,52 @@
+-- Full-text search over goals for GET /api/search. search holds the scorer,
+-- the game's teams and the title, without accents or apostrophes to match
+-- names.Fold, which builds the queries. unaccent isn't immutable, so triggers
+-- keep the column up to date instead of a generated column: on goal writes,
+-- and on goals of a game whose teams were renamed. The extension lives in
+-- public and is called by its full name, as the search_path may not include
+-- public (see Config.Schema).
+CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public;
+
+CREATE FUNCTION search_text(s TEXT) RETURNS TEXT AS $$
+  SELECT translate(public.unaccent('public.unaccent', s), '''’', '')
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION goal_search(description TEXT, goalscorer TEXT, home_team TEXT, away_team TEXT) RETURNS tsvector AS $$
+  SELECT setweight(to_tsvector('simple', search_text(coalesce(goalscorer, ''))), 'A') ||
+         setweight(to_tsvector('simple', search_text(coalesce(home_team, '') || ' ' || coalesce(away_team, ''))), 'B') ||
+         setweight(to_tsvector('simple', search_text(coalesce(description, ''))), 'C')
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE goals ADD COLUMN search tsvector;
+
+CREATE FUNCTION goals_search_update() RETURNS trigger AS $$
+BEGIN
+  NEW.search := goal_search(NEW.description, NEW.goalscorer,
+    (SELECT home_team FROM games WHERE id = NEW.game_id),
+    (SELECT away_team FROM games WHERE id = NEW.game_id));
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER goals_search BEFORE INSERT OR UPDATE OF description, goalscorer, game_id ON goals
+  FOR EACH ROW EXECUTE FUNCTION goals_search_update();
+
+CREATE FUNCTION games_search_update() RETURNS trigger AS $$
+BEGIN
+  UPDATE goals SET search = goal_search(description, goalscorer, NEW.home_team, NEW.away_team)
+  WHERE game_id = NEW.id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER games_search AFTER UPDATE OF home_team, away_team ON games
+  FOR EACH ROW WHEN (OLD.home_team IS DISTINCT FROM NEW.home_team OR OLD.away_team IS DISTINCT FROM NEW.away_team)
+  EXECUTE FUNCTION games_search_update();
+
+-- Filling the new column isn't a change clients need to sync
+ALTER TABLE goals DISABLE TRIGGER goals_changes;
+UPDATE goals g SET search = goal_search(g.description, g.goalscorer, gm.home_team, gm.away_team)
+FROM games gm WHERE gm.id = g.game_id;
+ALTER TABLE goals ENABLE TRIGGER goals_changes;
+
+CREATE INDEX idx_goals_search ON goals USING GIN (search);
@@ -0,0 +1,15 @@
+-- A game merged into another by hand leaves a redirect behind: the teams and
+-- kick-off it was stored under now lead ingestion to the game it was merged
+-- into, instead of recreating the duplicate on the next goal post. swapped is
+-- set when the teams are the other way round in the game merged into.
+CREATE TABLE game_redirects (
+  id SERIAL PRIMARY KEY,
+  home_team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  away_team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+  started_at TIMESTAMPTZ NOT NULL,
+  game_id INT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+  swapped BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX idx_game_redirects_teams_started_at ON game_redirects (home_team_id, away_team_id, started_at);
+CREATE INDEX idx_game_redirects_game_id ON game_redirects (game_id);
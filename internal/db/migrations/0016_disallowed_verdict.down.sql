@@ -0,0 +1,2 @@
+-- Nothing to undo: the goals cleared by the up migration weren't disallowed.
+SELECT 1;
@@ -0,0 +1,20 @@
+-- 0006 backfilled disallowed for any title mentioning VAR, including goals
+-- VAR let stand. Clear it on those unless it was set by hand, then recount
+-- the scores of their games, keeping hand-set scores like recountScore.
+CREATE TEMP TABLE cleared_games ON COMMIT DROP AS
+WITH cleared AS (
+  UPDATE goals SET disallowed = false
+  WHERE disallowed
+    AND NOT 'disallowed' = ANY(locked_fields)
+    AND description ~* '\mvar\M'
+    AND NOT description ~* '\m(disallowed|ruled out|chalked off)\M|\mvar\M.*\m(no goal|overturned)\M|\m(no goal|overturned)\M.*\mvar\M'
+  RETURNING game_id
+)
+SELECT DISTINCT game_id FROM cleared;
+
+UPDATE games SET
+  home_score = CASE WHEN 'home_score' = ANY(locked_fields) THEN home_score
+    ELSE (SELECT COALESCE(MAX(g.home_score), 0) FROM goals g WHERE g.game_id = games.id AND NOT g.disallowed) END,
+  away_score = CASE WHEN 'away_score' = ANY(locked_fields) THEN away_score
+    ELSE (SELECT COALESCE(MAX(g.away_score), 0) FROM goals g WHERE g.game_id = games.id AND NOT g.disallowed) END
+WHERE id IN (SELECT game_id FROM cleared_games);
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blooters/internal/events"
+	"blooters/internal/models"
+)
+
+// insertMirrors adds mirrors to a goal, skipping links it already has. It
+// returns how many were new.
+func insertMirrors(ctx context.Context, tx *sql.Tx, goalID int, mirrors []models.Mirror) (int64, error) {
+	if len(mirrors) == 0 {
+		return 0, nil
+	}
+	hosts, labels, urls := make([]string, len(mirrors)), make([]string, len(mirrors)), make([]string, len(mirrors))
+	for i, m := range mirrors {
+		hosts[i], labels[i], urls[i] = m.Host, m.Label, m.URL
+	}
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO goal_mirrors (goal_id, host, label, url)
+		 SELECT $1, t.host, t.label, t.url FROM unnest($2::text[], $3::text[], $4::text[]) WITH ORDINALITY AS t(host, label, url, n)
+		 ORDER BY t.n
+		 ON CONFLICT (goal_id, url) DO NOTHING`,
+		goalID, hosts, labels, urls,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert mirrors: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// attachMirrors loads the mirrors of all the given goals in one query. Goals
+// without mirrors get an empty list.
+func attachMirrors(ctx context.Context, q queryer, goals []*models.Goal) error {
+	if len(goals) == 0 {
+		return nil
+	}
+	ids := make([]int, len(goals))
+	byID := make(map[int]*models.Goal, len(goals))
+	for i, g := range goals {
+		g.Mirrors = []models.Mirror{}
+		ids[i] = g.ID
+		byID[g.ID] = g
+	}
+
+	rows, err := q.QueryContext(ctx,
+		"SELECT goal_id, host, label, url FROM goal_mirrors WHERE goal_id = ANY($1::int[]) ORDER BY goal_id, id", ids)
+	if err != nil {
+		return fmt.Errorf("failed to query mirrors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var m models.Mirror
+		if err := rows.Scan(&id, &m.Host, &m.Label, &m.URL); err != nil {
+			return fmt.Errorf("failed to scan mirror: %w", err)
+		}
+		byID[id].Mirrors = append(byID[id].Mirrors, m)
+	}
+	return rows.Err()
+}
+
+// MirrorsPoll is a goal whose mirrors comment is due to be polled.
+type MirrorsPoll struct {
+	GoalID    int
+	Url       string // the goal video, not a mirror of itself
+	RedditURL string
+	PostedAt  time.Time
+	Polls     int // polls done so far
+}
+
+// GoalsDueForMirrors returns up to limit goals whose next mirrors poll is due,
+// the most overdue first.
+func (s *Store) GoalsDueForMirrors(ctx context.Context, limit int) ([]MirrorsPoll, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, reddit_url, posted_at, mirrors_polls FROM goals
+		 WHERE mirrors_next_poll_at <= now() AND reddit_url <> ''
+		 ORDER BY mirrors_next_poll_at
+		 LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goals due for mirrors: %w", err)
+	}
+	defer rows.Close()
+
+	var polls []MirrorsPoll
+	for rows.Next() {
+		var p MirrorsPoll
+		if err := rows.Scan(&p.GoalID, &p.Url, &p.RedditURL, &p.PostedAt, &p.Polls); err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		polls = append(polls, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return polls, nil
+}
+
+// RecordMirrorsPoll stores the outcome of a mirrors poll: the mirrors comment,
+// unless it was corrected by hand, and any mirrors not known yet. The goal is
+// polled again at next, or never when next is zero. An empty mirrorsURL
+// records a poll that found nothing. Subscribers are told when the mirrors
+// changed.
+func (s *Store) RecordMirrorsPoll(ctx context.Context, goalID int, mirrorsURL string, mirrors []models.Mirror, next time.Time) error {
+	var nextPoll any
+	if !next.IsZero() {
+		nextPoll = next
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	goal := models.Goal{ID: goalID}
+	var urlChanged bool
+	err = tx.QueryRowContext(ctx,
+		`WITH old AS (SELECT mirrors_url FROM goals WHERE id = $1)
+		 UPDATE goals SET
+		   mirrors_polls = mirrors_polls + 1,
+		   mirrors_next_poll_at = $3,
+		   mirrors_url = CASE WHEN $2 = '' OR 'mirrors_url' = ANY(locked_fields) THEN mirrors_url ELSE $2 END
+		 WHERE id = $1
+		 RETURNING mirrors_url, mirrors_url <> (SELECT mirrors_url FROM old)`,
+		goalID, mirrorsURL, nextPoll,
+	).Scan(&goal.MirrorsURL, &urlChanged)
+	if err == sql.ErrNoRows {
+		// Removed since it was picked
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update mirrors: %w", err)
+	}
+	added, err := insertMirrors(ctx, tx, goalID, mirrors)
+	if err != nil {
+		return err
+	}
+	if urlChanged || added > 0 {
+		if err := attachMirrors(ctx, tx, []*models.Goal{&goal}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit mirrors: %w", err)
+	}
+
+	if urlChanged || added > 0 {
+		s.publish(events.TypeMirrors, models.MirrorsUpdate{GoalID: goalID, MirrorsURL: goal.MirrorsURL, Mirrors: goal.Mirrors})
+	}
+	return nil
+}
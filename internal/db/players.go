@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"blooters/internal/models"
+	"blooters/internal/names"
+)
+
+// playerCandidate is a known spelling of a player who scored for one of the
+// teams involved.
+type playerCandidate struct {
+	ID  int
+	Key string
+}
+
+// knownPlayer is a player known by the exact spelling looked up. Local is set
+// when the player scored for one of the teams involved.
+type knownPlayer struct {
+	ID    int
+	Name  string
+	Local bool
+}
+
+// pickKnown picks the player a spelling refers to among those known by exactly
+// that spelling: one who scored for the teams involved, or else the only one
+// known, say after a transfer. A player of another club doesn't count when
+// several share the spelling or when it could be one of the candidates, the
+// spellings of players who scored for the teams involved.
+func pickKnown(key string, known []knownPlayer, candidates []playerCandidate) (knownPlayer, bool) {
+	for _, p := range known {
+		if p.Local {
+			return p, true
+		}
+	}
+	if len(known) != 1 {
+		return knownPlayer{}, false
+	}
+	for _, c := range candidates {
+		if sameScorer(key, c.Key) {
+			return knownPlayer{}, false
+		}
+	}
+	return known[0], true
+}
+
+// matchPlayer picks the player a spelling refers to among the candidates. A
+// spelling matches a player when one name is the tail of the other
+// ("haaland" and "erling haaland"), allowing an initial for the first name
+// ("e haaland"). Only an unambiguous match counts.
+func matchPlayer(key string, candidates []playerCandidate) (int, bool) {
+	found := 0
+	for _, c := range candidates {
+		if !sameScorer(key, c.Key) {
+			continue
+		}
+		if found != 0 && found != c.ID {
+			return 0, false
+		}
+		found = c.ID
+	}
+	return found, found != 0
+}
+
+func sameScorer(a, b string) bool {
+	short, long := strings.Fields(a), strings.Fields(b)
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) == 0 {
+		return false
+	}
+	tail := long[len(long)-len(short):]
+	for i := range short {
+		if short[i] == tail[i] {
+			continue
+		}
+		// "e haaland" for "erling haaland", only for the leading name
+		if i != 0 || len(short) == 1 || len(short[i]) != 1 || !strings.HasPrefix(tail[i], short[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePlayer maps a goalscorer as typed to a canonical player who scored
+// for homeID or awayID, see pickKnown and matchPlayer, and registers a new
+// player when nothing matches. It returns the player's ID and canonical name,
+// or 0 when the scorer is unknown.
+func resolvePlayer(ctx context.Context, tx *sql.Tx, typed string, homeID, awayID int) (int, string, error) {
+	key := names.PlayerKey(typed)
+	if key == "" {
+		return 0, typed, nil
+	}
+
+	// Players known by this spelling, those who scored for these teams first
+	rows, err := tx.QueryContext(ctx,
+		`SELECT p.id, p.name, EXISTS (
+		   SELECT 1 FROM goals gl JOIN games g ON g.id = gl.game_id
+		   WHERE gl.player_id = p.id AND (g.home_team_id IN ($2, $3) OR g.away_team_id IN ($2, $3))
+		 ) AS local
+		 FROM player_aliases a JOIN players p ON p.id = a.player_id
+		 WHERE a.key = $1
+		 ORDER BY local DESC, p.id`,
+		key, homeID, awayID,
+	)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to look up player %q: %w", typed, err)
+	}
+	var known []knownPlayer
+	for rows.Next() {
+		var p knownPlayer
+		if err := rows.Scan(&p.ID, &p.Name, &p.Local); err != nil {
+			rows.Close()
+			return 0, "", err
+		}
+		known = append(known, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("failed to look up player %q: %w", typed, err)
+	}
+	if len(known) > 0 && known[0].Local {
+		return known[0].ID, known[0].Name, nil
+	}
+
+	// Spellings of the players who scored for one of these teams, for a new
+	// spelling of one of them, e.g. "Haaland" after "Erling Haaland"
+	rows, err = tx.QueryContext(ctx,
+		`SELECT DISTINCT p.id, a.key
+		 FROM goals gl
+		 JOIN games g ON g.id = gl.game_id
+		 JOIN players p ON p.id = gl.player_id
+		 JOIN player_aliases a ON a.player_id = p.id
+		 WHERE g.home_team_id IN ($1, $2) OR g.away_team_id IN ($1, $2)`,
+		homeID, awayID,
+	)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to query player candidates: %w", err)
+	}
+	var candidates []playerCandidate
+	for rows.Next() {
+		var c playerCandidate
+		if err := rows.Scan(&c.ID, &c.Key); err != nil {
+			rows.Close()
+			return 0, "", err
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("failed to query player candidates: %w", err)
+	}
+
+	if p, ok := pickKnown(key, known, candidates); ok {
+		return p.ID, p.Name, nil
+	}
+
+	typed = names.PlayerName(typed)
+	if id, ok := matchPlayer(key, candidates); ok {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO player_aliases (key, player_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", key, id); err != nil {
+			return 0, "", fmt.Errorf("failed to insert player alias: %w", err)
+		}
+		// Keep the fullest spelling as the canonical name
+		var name string
+		err := tx.QueryRowContext(ctx,
+			`UPDATE players SET name = CASE
+			   WHEN array_length(regexp_split_to_array($2, '\s+'), 1) > array_length(regexp_split_to_array(name, '\s+'), 1)
+			   THEN $2 ELSE name END
+			 WHERE id = $1
+			 RETURNING name`,
+			id, typed,
+		).Scan(&name)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to update player: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE goals SET goalscorer = $2
+			 WHERE player_id = $1 AND goalscorer <> $2 AND NOT 'goalscorer' = ANY(locked_fields)`, id, name); err != nil {
+			return 0, "", fmt.Errorf("failed to rename player goals: %w", err)
+		}
+		return id, name, nil
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO players (name) VALUES ($1) RETURNING id", typed).Scan(&id)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to insert player %q: %w", typed, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO player_aliases (key, player_id) VALUES ($1, $2)", key, id); err != nil {
+		return 0, "", fmt.Errorf("failed to insert player alias: %w", err)
+	}
+	return id, typed, nil
+}
+
+// PlayerGoals returns the players known by name, under any of their spellings,
+// and up to limit of their goals, newest first, with their game's teams and
+// mirrors. A spelling can belong to several players, whose goals are listed
+// together. It is ErrNotFound when no player goes by name.
+func (s *Store) PlayerGoals(ctx context.Context, name string, limit int) ([]models.Player, []models.Goal, error) {
+	key := names.PlayerKey(name)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT p.id, p.name, array_agg(a.key ORDER BY a.key)
+		 FROM players p JOIN player_aliases a ON a.player_id = p.id
+		 WHERE p.id IN (SELECT player_id FROM player_aliases WHERE key = $1)
+		 GROUP BY p.id ORDER BY p.id`, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query players: %w", err)
+	}
+	defer rows.Close()
+
+	var players []models.Player
+	var ids []int
+	for rows.Next() {
+		var p models.Player
+		if err := rows.Scan(&p.ID, &p.Name, typeMap.SQLScanner(&p.Aliases)); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan player: %w", err)
+		}
+		players = append(players, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to query players: %w", err)
+	}
+	rows.Close()
+	if len(players) == 0 {
+		return nil, nil, ErrNotFound
+	}
+
+	goals, err := queryGoals(ctx, s.db, "player_id = ANY($1::int[]) ORDER BY posted_at DESC, id DESC LIMIT $2", ids, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return players, goals, nil
+}
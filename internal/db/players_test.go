@@ -0,0 +1,65 @@
+package db
+
+import "testing"
+
+func TestMatchPlayer(t *testing.T) {
+	candidates := []playerCandidate{
+		{ID: 1, Key: "erling haaland"},
+		{ID: 2, Key: "bernardo silva"},
+		{ID: 3, Key: "david silva"},
+		{ID: 4, Key: "kevin de bruyne"},
+		{ID: 4, Key: "de bruyne"},
+	}
+
+	tests := []struct {
+		key    string
+		wantID int
+		wantOK bool
+	}{
+		{"haaland", 1, true},
+		{"erling haaland", 1, true},
+		{"e haaland", 1, true},
+		{"de bruyne", 4, true},
+		{"bruyne", 4, true},
+		{"silva", 0, false}, // two Silvas
+		{"bernardo silva", 2, true},
+		{"x haaland", 0, false},
+		{"foden", 0, false},
+	}
+
+	for _, tt := range tests {
+		id, ok := matchPlayer(tt.key, candidates)
+		if id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("matchPlayer(%q) = %d, %v; want %d, %v", tt.key, id, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestPickKnown(t *testing.T) {
+	local := knownPlayer{ID: 1, Name: "Bernardo Silva", Local: true}
+	elsewhere := knownPlayer{ID: 2, Name: "Thiago Silva"}
+	another := knownPlayer{ID: 3, Name: "David Silva"}
+	candidates := []playerCandidate{{ID: 4, Key: "rodrygo"}, {ID: 5, Key: "vinicius junior"}}
+
+	tests := []struct {
+		name       string
+		key        string
+		known      []knownPlayer
+		candidates []playerCandidate
+		wantID     int
+		wantOK     bool
+	}{
+		{"scored for these teams", "silva", []knownPlayer{local, elsewhere}, candidates, 1, true},
+		{"only one elsewhere", "silva", []knownPlayer{elsewhere}, candidates, 2, true},
+		{"several elsewhere", "silva", []knownPlayer{elsewhere, another}, candidates, 0, false},
+		{"a similar name here", "junior", []knownPlayer{{ID: 6, Name: "Junior"}}, candidates, 0, false},
+		{"unknown", "silva", nil, candidates, 0, false},
+	}
+
+	for _, tt := range tests {
+		p, ok := pickKnown(tt.key, tt.known, tt.candidates)
+		if p.ID != tt.wantID || ok != tt.wantOK {
+			t.Errorf("%s: pickKnown(%q) = %d, %v; want %d, %v", tt.name, tt.key, p.ID, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
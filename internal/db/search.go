@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"blooters/internal/models"
+	"blooters/internal/names"
+)
+
+// searchQuery turns what a user typed into a tsquery matching goals that have
+// every word, each as a prefix so that partial words match while typing, e.g.
+// "Salah Ever" -> "salah:* & ever:*". Folding leaves only letters and digits,
+// so the input can't inject tsquery operators. It is "" when nothing is left.
+func searchQuery(q string) string {
+	words := strings.Fields(names.Fold(q))
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// SearchGoals returns up to limit goals matching q by scorer, teams or title,
+// best match first, with their game's teams and their mirrors. Scorer matches
+// rank above team matches, which rank above the rest of the title.
+func (s *Store) SearchGoals(ctx context.Context, q string, limit int) ([]models.Goal, error) {
+	query := searchQuery(q)
+	if query == "" {
+		return nil, fmt.Errorf("%w: search has no letters or digits", ErrInvalid)
+	}
+	return queryGoals(ctx, s.db,
+		`search @@ to_tsquery('simple', $1)
+		 ORDER BY ts_rank(search, to_tsquery('simple', $1)) DESC, posted_at DESC, id DESC
+		 LIMIT $2`, query, limit)
+}
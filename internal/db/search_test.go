@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"blooters/internal/models"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Salah Everton", "salah:* & everton:*"},
+		{"  salah  ever", "salah:* & ever:*"},
+		{"Atlético", "atletico:*"},
+		{"Nott'm Forest", "nottm:* & forest:*"},
+		{"saka & !(x | y):*", "saka:* & x:* & y:*"},
+		{"90+2", "90:* & 2:*"},
+		{"&|!", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := searchQuery(tt.in); got != tt.want {
+			t.Errorf("searchQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchGoals(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	goals := []models.Goal{
+		{
+			HomeTeam: "Liverpool", AwayTeam: "Everton", Goalscorer: "Mohamed Salah", Minute: "23", HomeScore: 1,
+			Description: "Liverpool [1]-0 Everton - Mohamed Salah 23'",
+			Url:         "https://streamable.com/salah", RedditURL: "https://www.reddit.com/r/soccer/comments/salah",
+		},
+		{
+			HomeTeam: "Atlético Madrid", AwayTeam: "Sevilla", Goalscorer: "Antoine Griezmann", Minute: "10", HomeScore: 1,
+			Description: "Atlético Madrid [1]-0 Sevilla - Antoine Griezmann 10'",
+			Url:         "https://streamable.com/griezmann", RedditURL: "https://www.reddit.com/r/soccer/comments/griezmann",
+		},
+	}
+	if _, err := s.StoreGoals(ctx, goals); err != nil {
+		t.Fatalf("StoreGoals() error = %v", err)
+	}
+
+	for q, want := range map[string]string{
+		"salah everton":  "Mohamed Salah",
+		"sal ever":       "Mohamed Salah",
+		"atletico":       "Antoine Griezmann",
+		"ATLÉTICO griez": "Antoine Griezmann",
+	} {
+		got, err := s.SearchGoals(ctx, q, 10)
+		if err != nil {
+			t.Fatalf("SearchGoals(%q) error = %v", q, err)
+		}
+		if len(got) != 1 || got[0].Goalscorer != want || got[0].HomeTeam == "" {
+			t.Errorf("SearchGoals(%q) = %+v, want the goal of %s with its teams", q, got, want)
+		}
+	}
+	if got, err := s.SearchGoals(ctx, "salah sevilla", 10); err != nil || len(got) != 0 {
+		t.Errorf("SearchGoals(salah sevilla) = %+v, %v; want nothing", got, err)
+	}
+}
@@ -0,0 +1,338 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"blooters/internal/events"
+	"blooters/internal/models"
+	"blooters/internal/names"
+)
+
+//go:embed seed/teams.txt
+var teamSeed []byte
+
+// ErrNotFound is returned when the requested row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// typeMap scans Postgres arrays, which database/sql can't do on its own.
+var typeMap = pgtype.NewMap()
+
+// Alias sources, in increasing precedence for seeding: seeding replaces auto
+// and seed aliases but never one an admin added.
+const (
+	aliasAuto  = "auto"
+	aliasSeed  = "seed"
+	aliasAdmin = "admin"
+)
+
+// seedTeam is one line of the seed file.
+type seedTeam struct {
+	Name    string
+	Aliases []string
+}
+
+// parseTeamSeed reads "Canonical Name: alias, alias" lines, skipping blank
+// lines and # comments.
+func parseTeamSeed(data []byte) ([]seedTeam, error) {
+	var teams []seedTeam
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("teams seed line %d: want \"Name: alias, ...\"", n)
+		}
+		t := seedTeam{Name: name}
+		for _, alias := range strings.Split(rest, ",") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				t.Aliases = append(t.Aliases, alias)
+			}
+		}
+		teams = append(teams, t)
+	}
+	return teams, sc.Err()
+}
+
+// teamRef is a resolved team as stored on a game.
+type teamRef struct {
+	ID   int
+	Name string
+	Slug string
+}
+
+// resolveTeam maps a team name as typed in a title to its canonical team,
+// registering it as a new team on first sight.
+func resolveTeam(ctx context.Context, tx *sql.Tx, typed string) (teamRef, error) {
+	key := names.TeamKey(typed)
+	lookup := func() (teamRef, error) {
+		var t teamRef
+		err := tx.QueryRowContext(ctx,
+			"SELECT t.id, t.name, t.slug FROM team_aliases a JOIN teams t ON t.id = a.team_id WHERE a.key = $1",
+			key,
+		).Scan(&t.ID, &t.Name, &t.Slug)
+		return t, err
+	}
+
+	t, err := lookup()
+	if err != sql.ErrNoRows {
+		if err != nil {
+			return t, fmt.Errorf("failed to look up team %q: %w", typed, err)
+		}
+		return t, nil
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO teams (name, slug) VALUES ($1, $2)
+		 ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+		 RETURNING id`,
+		strings.TrimSpace(typed), names.Slug(key),
+	).Scan(&id)
+	if err != nil {
+		return t, fmt.Errorf("failed to insert team %q: %w", typed, err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO team_aliases (key, team_id, source) VALUES ($1, $2, $3) ON CONFLICT (key) DO NOTHING",
+		key, id, aliasAuto,
+	)
+	if err != nil {
+		return t, fmt.Errorf("failed to insert team alias %q: %w", typed, err)
+	}
+
+	// Another writer may have registered the alias first; theirs wins
+	if t, err = lookup(); err != nil {
+		return t, fmt.Errorf("failed to look up team %q: %w", typed, err)
+	}
+	return t, nil
+}
+
+// setAlias points the alias key at teamID unless a higher precedence source
+// owns it. Games stored under that spelling move along with the alias, except
+// for teams corrected by hand, and the team it pointed at before is dropped
+// once nothing refers to it.
+func setAlias(ctx context.Context, tx *sql.Tx, key string, teamID int, source string) error {
+	var oldTeam int
+	var oldSource string
+	err := tx.QueryRowContext(ctx,
+		"SELECT team_id, source FROM team_aliases WHERE key = $1 FOR UPDATE", key,
+	).Scan(&oldTeam, &oldSource)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO team_aliases (key, team_id, source) VALUES ($1, $2, $3)", key, teamID, source)
+		if err != nil {
+			return fmt.Errorf("failed to insert team alias: %w", err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to query team alias: %w", err)
+	case oldTeam == teamID || (oldSource == aliasAdmin && source != aliasAdmin):
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE team_aliases SET team_id = $2, source = $3 WHERE key = $1", key, teamID, source); err != nil {
+		return fmt.Errorf("failed to update team alias: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE games SET home_team_id = $2
+		 WHERE home_key = $1 AND home_team_id <> $2 AND NOT 'home_team' = ANY(locked_fields)`, key, teamID); err != nil {
+		return fmt.Errorf("failed to move games to team: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE games SET away_team_id = $2
+		 WHERE away_key = $1 AND away_team_id <> $2 AND NOT 'away_team' = ANY(locked_fields)`, key, teamID); err != nil {
+		return fmt.Errorf("failed to move games to team: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM teams t WHERE id = $1
+		 AND NOT EXISTS (SELECT 1 FROM team_aliases WHERE team_id = t.id)
+		 AND NOT EXISTS (SELECT 1 FROM games WHERE home_team_id = t.id OR away_team_id = t.id)`,
+		oldTeam,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove unused team: %w", err)
+	}
+	return nil
+}
+
+// syncTeamNames copies canonical team names onto the games referring to them.
+func syncTeamNames(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE games g SET home_team = h.name, away_team = a.name
+		 FROM teams h, teams a
+		 WHERE h.id = g.home_team_id AND a.id = g.away_team_id
+		   AND (g.home_team <> h.name OR g.away_team <> a.name)`)
+	if err != nil {
+		return fmt.Errorf("failed to update game team names: %w", err)
+	}
+	return nil
+}
+
+// SeedTeams applies the embedded alias file: canonical teams are created or
+// renamed and their aliases point at them, except where an admin decided
+// otherwise. It is idempotent and runs on every start.
+func (s *Store) SeedTeams(ctx context.Context) error {
+	seed, err := parseTeamSeed(teamSeed)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, st := range seed {
+		key := names.TeamKey(st.Name)
+		var id int
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO teams (name, slug) VALUES ($1, $2)
+			 ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name
+			 RETURNING id`,
+			st.Name, names.Slug(key),
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to seed team %q: %w", st.Name, err)
+		}
+		if err := setAlias(ctx, tx, key, id, aliasSeed); err != nil {
+			return fmt.Errorf("failed to seed team %q: %w", st.Name, err)
+		}
+		for _, alias := range st.Aliases {
+			if err := setAlias(ctx, tx, names.TeamKey(alias), id, aliasSeed); err != nil {
+				return fmt.Errorf("failed to seed alias %q: %w", alias, err)
+			}
+		}
+	}
+
+	if err := syncTeamNames(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit team seed: %w", err)
+	}
+	s.publish(events.TypeChange, models.Change{Reason: models.ChangeTeams})
+	return nil
+}
+
+// Teams lists every team with its known spellings, by name.
+func (s *Store) Teams(ctx context.Context) ([]models.Team, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT t.id, t.name, t.slug, COALESCE(array_agg(a.key ORDER BY a.key) FILTER (WHERE a.key IS NOT NULL), '{}')
+		 FROM teams t LEFT JOIN team_aliases a ON a.team_id = t.id
+		 GROUP BY t.id ORDER BY t.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.Slug, typeMap.SQLScanner(&t.Aliases)); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+// Team returns one team with its known spellings, or ErrNotFound.
+func (s *Store) Team(ctx context.Context, id int) (models.Team, error) {
+	return s.team(ctx, s.db, id)
+}
+
+// TeamByName returns the team known by name, as any of its spellings or its
+// slug, or ErrNotFound. A spelling wins over a slug, as in ingestion, should
+// they name different teams.
+func (s *Store) TeamByName(ctx context.Context, name string) (models.Team, error) {
+	var id int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM (
+		   SELECT team_id AS id, 1 AS priority FROM team_aliases WHERE key = $1
+		   UNION ALL
+		   SELECT id, 2 FROM teams WHERE slug = $2
+		 ) found
+		 ORDER BY priority
+		 LIMIT 1`,
+		names.TeamKey(name), strings.ToLower(name),
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.Team{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("failed to look up team %q: %w", name, err)
+	}
+	return s.team(ctx, s.db, id)
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *Store) team(ctx context.Context, q queryer, id int) (models.Team, error) {
+	var t models.Team
+	err := q.QueryRowContext(ctx,
+		`SELECT t.id, t.name, t.slug, COALESCE(array_agg(a.key ORDER BY a.key) FILTER (WHERE a.key IS NOT NULL), '{}')
+		 FROM teams t LEFT JOIN team_aliases a ON a.team_id = t.id
+		 WHERE t.id = $1 GROUP BY t.id`, id,
+	).Scan(&t.ID, &t.Name, &t.Slug, typeMap.SQLScanner(&t.Aliases))
+	if err == sql.ErrNoRows {
+		return t, ErrNotFound
+	}
+	if err != nil {
+		return t, fmt.Errorf("failed to query team: %w", err)
+	}
+	return t, nil
+}
+
+// AddTeamAlias maps another spelling to the team. The alias takes precedence
+// over the seed file, and games already stored under that spelling move to
+// the team.
+func (s *Store) AddTeamAlias(ctx context.Context, teamID int, alias string) (models.Team, error) {
+	key := names.TeamKey(alias)
+	if key == "" {
+		return models.Team{}, fmt.Errorf("alias %q has no letters or digits", alias)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Team{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.team(ctx, tx, teamID); err != nil {
+		return models.Team{}, err
+	}
+	if err := setAlias(ctx, tx, key, teamID, aliasAdmin); err != nil {
+		return models.Team{}, err
+	}
+	if err := syncTeamNames(ctx, tx); err != nil {
+		return models.Team{}, err
+	}
+	t, err := s.team(ctx, tx, teamID)
+	if err != nil {
+		return t, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Team{}, fmt.Errorf("failed to commit alias: %w", err)
+	}
+	s.publish(events.TypeChange, models.Change{Reason: models.ChangeTeams})
+	return t, nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+
+	"blooters/internal/names"
+)
+
+func TestParseTeamSeed(t *testing.T) {
+	teams, err := parseTeamSeed([]byte("# comment\n\nTottenham Hotspur: Spurs, Tottenham ,\nChelsea:\n"))
+	if err != nil {
+		t.Fatalf("parseTeamSeed() error = %v", err)
+	}
+	if len(teams) != 2 {
+		t.Fatalf("parseTeamSeed() = %d teams, want 2", len(teams))
+	}
+	if got := teams[0]; got.Name != "Tottenham Hotspur" || len(got.Aliases) != 2 || got.Aliases[1] != "Tottenham" {
+		t.Errorf("parseTeamSeed()[0] = %+v", got)
+	}
+	if got := teams[1]; got.Name != "Chelsea" || len(got.Aliases) != 0 {
+		t.Errorf("parseTeamSeed()[1] = %+v", got)
+	}
+
+	if _, err := parseTeamSeed([]byte("Spurs, Tottenham\n")); err == nil {
+		t.Error("parseTeamSeed() accepted a line without a colon")
+	}
+}
+
+// Every spelling in the embedded seed must map to exactly one team, otherwise
+// seeding would flip the alias between teams depending on line order.
+func TestTeamSeedIsUnambiguous(t *testing.T) {
+	teams, err := parseTeamSeed(teamSeed)
+	if err != nil {
+		t.Fatalf("parseTeamSeed() error = %v", err)
+	}
+
+	owner := map[string]string{}
+	for _, team := range teams {
+		for _, spelling := range append([]string{team.Name}, team.Aliases...) {
+			key := names.TeamKey(spelling)
+			if key == "" {
+				t.Errorf("%s: alias %q normalizes to nothing", team.Name, spelling)
+			}
+			if prev, ok := owner[key]; ok && prev != team.Name {
+				t.Errorf("alias %q (%s) belongs to both %s and %s", spelling, key, prev, team.Name)
+			}
+			owner[key] = team.Name
+		}
+	}
+}
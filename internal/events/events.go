@@ -0,0 +1,129 @@
+package events
+
+import (
+	"sync"
+)
+
+// Event types pushed to stream subscribers
+const (
+	TypeGoal    = "goal"
+	TypeScore   = "score"
+	TypeMirrors = "mirrors"
+	// TypeCorrection is a goal or game corrected, reassigned or merged by hand.
+	TypeCorrection = "correction"
+	// TypeChange is any other write that changes the games, like a cleanup.
+	TypeChange = "change"
+	// TypeReset tells a resuming client that the events it missed are no longer
+	// buffered and it should refetch /api/games.
+	TypeReset = "reset"
+)
+
+// Event is a single change notification. IDs are assigned by the broker and
+// increase monotonically for the lifetime of the process.
+type Event struct {
+	ID   uint64
+	Type string
+	Data any
+}
+
+// Broker fans events out to subscribers and keeps a bounded history so that a
+// reconnecting client can catch up from its Last-Event-ID.
+type Broker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event
+	size    int
+	subs    map[chan Event]struct{}
+	closed  bool
+}
+
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		nextID: 1,
+		size:   historySize,
+		subs:   make(map[chan Event]struct{}),
+	}
+}
+
+// Publish records the event and delivers it to every subscriber. Slow
+// subscribers whose buffer is full are dropped rather than blocking ingestion;
+// they will resume from history when they reconnect.
+func (b *Broker) Publish(eventType string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	ev := Event{ID: b.nextID, Type: eventType, Data: data}
+	b.nextID++
+
+	b.history = append(b.history, ev)
+	if len(b.history) > b.size {
+		b.history = b.history[len(b.history)-b.size:]
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. If lastID is non-zero, the returned
+// backlog holds every buffered event after lastID; when lastID is older than
+// the buffered history the backlog starts with a reset event instead.
+// The channel is closed when the subscriber is dropped or the broker closes.
+func (b *Broker) Subscribe(lastID uint64) (ch chan Event, backlog []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch = make(chan Event, 64)
+	if b.closed {
+		close(ch)
+		return ch, nil
+	}
+	b.subs[ch] = struct{}{}
+
+	latest := b.nextID - 1
+	if lastID == 0 || lastID == latest {
+		return ch, nil
+	}
+
+	// An ID from the future means the process restarted and IDs began again.
+	if lastID > latest || len(b.history) == 0 || lastID+1 < b.history[0].ID {
+		backlog = append(backlog, Event{ID: latest, Type: TypeReset})
+		return ch, backlog
+	}
+
+	for _, ev := range b.history {
+		if ev.ID > lastID {
+			backlog = append(backlog, ev)
+		}
+	}
+	return ch, backlog
+}
+
+// Unsubscribe removes the subscriber and closes its channel.
+func (b *Broker) Unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Close disconnects every subscriber and ignores further publishes.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
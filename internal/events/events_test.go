@@ -0,0 +1,62 @@
+package events
+
+import "testing"
+
+func TestSubscribeResume(t *testing.T) {
+	b := NewBroker(3)
+	for i := 0; i < 5; i++ {
+		b.Publish(TypeGoal, i)
+	}
+	// History now holds IDs 3, 4 and 5.
+
+	tests := []struct {
+		lastID  uint64
+		wantIDs []uint64
+		reset   bool
+	}{
+		{lastID: 0, wantIDs: nil},
+		{lastID: 5, wantIDs: nil},
+		{lastID: 3, wantIDs: []uint64{4, 5}},
+		{lastID: 2, wantIDs: []uint64{3, 4, 5}},
+		{lastID: 1, reset: true},
+		{lastID: 42, reset: true},
+	}
+
+	for _, tt := range tests {
+		ch, backlog := b.Subscribe(tt.lastID)
+		b.Unsubscribe(ch)
+
+		if tt.reset {
+			if len(backlog) != 1 || backlog[0].Type != TypeReset {
+				t.Errorf("Subscribe(%d) backlog = %v, want a single reset event", tt.lastID, backlog)
+			}
+			continue
+		}
+		if len(backlog) != len(tt.wantIDs) {
+			t.Errorf("Subscribe(%d) backlog has %d events, want %d", tt.lastID, len(backlog), len(tt.wantIDs))
+			continue
+		}
+		for i, ev := range backlog {
+			if ev.ID != tt.wantIDs[i] {
+				t.Errorf("Subscribe(%d) backlog[%d].ID = %d, want %d", tt.lastID, i, ev.ID, tt.wantIDs[i])
+			}
+		}
+	}
+}
+
+func TestPublishDelivers(t *testing.T) {
+	b := NewBroker(8)
+	ch, _ := b.Subscribe(0)
+
+	b.Publish(TypeScore, "1-0")
+	ev := <-ch
+	if ev.ID != 1 || ev.Type != TypeScore {
+		t.Errorf("got event %+v, want id 1 of type %q", ev, TypeScore)
+	}
+
+	b.Close()
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after Close")
+	}
+	b.Publish(TypeGoal, nil) // must not panic after Close
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"blooters/internal/db"
+	"blooters/internal/models"
+)
+
+// Page sizes of ChangesHandler.
+const (
+	defaultChangesLimit = 500
+	maxChangesLimit     = 2000
+)
+
+// ChangesHandler lists the games and goals written since a revision, e.g.
+// GET /api/changes?since=1234, so that clients can sync without downloading
+// every game again. A client starts with since=0, then passes back the
+// revision of each response, asking again right away while more is set.
+// Deleted games and goals come as tombstones; when those a client needs were
+// pruned it gets a 410 and must start over from since=0.
+func ChangesHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var since int64
+		if v := q.Get("since"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid since %q: want a revision from a previous response", v))
+				return
+			}
+			since = n
+		}
+		limit, err := parseLimit(q, defaultChangesLimit, maxChangesLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		set, err := store.Changes(r.Context(), since, limit)
+		if errors.Is(err, db.ErrResync) {
+			writeError(w, http.StatusGone, "Revision too old, sync again from since=0")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load changes since %d: %v", since, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load changes")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, models.ChangesResponse{
+			Revision: set.Revision,
+			Games:    set.Games,
+			Goals:    set.Goals,
+			Deleted:  set.Deleted,
+			More:     set.More,
+			Status:   http.StatusOK,
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"blooters/internal/db"
+	"blooters/internal/middleware"
+	"blooters/internal/models"
+)
+
+// PatchGoalHandler corrects a goal, e.g. PATCH /api/goals/12
+// {"goalscorer": "Bukayo Saka", "side": "home"}, or moves it to another game
+// with {"game_id": 7}. Corrected fields are kept by later ingestion.
+func PatchGoalHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid goal id")
+			return
+		}
+
+		var patch db.GoalPatch
+		if err := decodeStrict(r, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid goal correction: "+err.Error())
+			return
+		}
+
+		actor := middleware.Actor(r.Context())
+		goal, err := store.PatchGoal(r.Context(), actor, id, patch)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeError(w, http.StatusNotFound, "Goal not found")
+			return
+		case errors.Is(err, db.ErrInvalid):
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		case err != nil:
+			log.Printf("Failed to correct goal %d: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to correct goal")
+			return
+		}
+
+		log.Printf("%s corrected goal %d", actor, id)
+		writeJSON(w, http.StatusOK, models.GoalResponse{Goal: goal, Status: http.StatusOK})
+	}
+}
+
+// PatchGameHandler corrects a game's teams or score, e.g. PATCH /api/games/7
+// {"home_score": 2}, or merges a duplicate into another game with
+// {"merge_into": 6}, which responds with the surviving game.
+func PatchGameHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid game id")
+			return
+		}
+
+		var patch db.GamePatch
+		if err := decodeStrict(r, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid game correction: "+err.Error())
+			return
+		}
+
+		actor := middleware.Actor(r.Context())
+		game, err := store.PatchGame(r.Context(), actor, id, patch)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeError(w, http.StatusNotFound, "Game not found")
+			return
+		case errors.Is(err, db.ErrInvalid):
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		case err != nil:
+			log.Printf("Failed to correct game %d: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to correct game")
+			return
+		}
+
+		if patch.MergeInto != nil {
+			log.Printf("%s merged game %d into %d", actor, id, game.ID)
+		} else {
+			log.Printf("%s corrected game %d", actor, id)
+		}
+		writeJSON(w, http.StatusOK, models.GameResponse{Game: game, Status: http.StatusOK})
+	}
+}
+
+// AuditLogHandler lists the corrections made to a goal or game, e.g.
+// GET /api/admin/audit/goal/12.
+func AuditLogHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entity := r.PathValue("entity")
+		if entity != "goal" && entity != "game" {
+			writeError(w, http.StatusBadRequest, "Entity must be goal or game")
+			return
+		}
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid "+entity+" id")
+			return
+		}
+
+		entries, err := store.AuditLog(r.Context(), entity, id)
+		if err != nil {
+			log.Printf("Failed to load audit log of %s %d: %v", entity, id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load audit log")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.AuditResponse{Entries: entries, Status: http.StatusOK})
+	}
+}
+
+// decodeStrict reads a JSON body into v, rejecting unknown fields so that a
+// misspelled field isn't silently ignored.
+func decodeStrict(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"blooters/internal/db"
+	"blooters/internal/middleware"
+	"blooters/internal/models"
+	"blooters/internal/reddit"
+)
+
+// defaultFailuresLimit caps a parse failures listing without ?limit.
+const defaultFailuresLimit = 100
+
+// ParseFailuresHandler lists quarantined posts, newest first. They can be
+// filtered with ?reason=no_score, ?promoted=true and ?limit=50.
+func ParseFailuresHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFailuresFilter(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		failures, err := store.ListParseFailures(r.Context(), filter)
+		if err != nil {
+			log.Printf("Failed to load parse failures: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to load parse failures")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.ParseFailuresResponse{Failures: failures, Status: http.StatusOK})
+	}
+}
+
+// parseFailuresFilter reads the ParseFailuresHandler query parameters.
+func parseFailuresFilter(q url.Values) (db.ParseFailuresFilter, error) {
+	f := db.ParseFailuresFilter{Reason: q.Get("reason"), Limit: defaultFailuresLimit}
+	if v := q.Get("promoted"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid promoted %q: want true or false", v)
+		}
+		f.Promoted = b
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return f, fmt.Errorf("invalid limit %q: want a positive number", v)
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+// ReparseHandler runs the parser again over the pending failures and promotes
+// those that now parse, e.g. after a parser fix.
+func ReparseHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		checked, promoted, err := reddit.Reparse(r.Context(), store)
+		if err != nil {
+			log.Printf("Failed to re-parse parse failures: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to re-parse")
+			return
+		}
+
+		log.Printf("%s re-parsed %d parse failures, %d promoted", middleware.Actor(r.Context()), checked, len(promoted))
+		writeJSON(w, http.StatusOK, models.ReparseResponse{Checked: checked, Promoted: promoted, Status: http.StatusOK})
+	}
+}
+
+// PromoteRequest is the optional body of PromoteParseFailureHandler. Title is
+// parsed instead of the post's own title when set.
+type PromoteRequest struct {
+	Title string `json:"title"`
+}
+
+// PromoteParseFailureHandler stores the goal of a quarantined post, e.g.
+// POST /api/admin/parse-failures/12/promote, optionally with
+// {"title": "Arsenal [1]-0 Chelsea - Bukayo Saka 12'"} when the post's own
+// title doesn't parse.
+func PromoteParseFailureHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid parse failure id")
+			return
+		}
+
+		var req PromoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, `Body must be empty or {"title": "<goal title>"}`)
+			return
+		}
+
+		failure, err := store.ParseFailure(r.Context(), id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Parse failure not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load parse failure %d: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load parse failure")
+			return
+		}
+
+		failure, err = reddit.Promote(r.Context(), store, failure, req.Title)
+		var pe *reddit.ParseError
+		if errors.As(err, &pe) {
+			writeError(w, http.StatusUnprocessableEntity, pe.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to promote parse failure %d: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to promote parse failure")
+			return
+		}
+
+		log.Printf("%s promoted parse failure %d to goal %d", middleware.Actor(r.Context()), failure.ID, failure.GoalID)
+		writeJSON(w, http.StatusOK, models.ParseFailureResponse{Failure: failure, Status: http.StatusOK})
+	}
+}
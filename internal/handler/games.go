@@ -1,38 +1,183 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
+	"time"
 
 	"blooters/internal/db"
 	"blooters/internal/models"
 )
 
-func GamesHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("Fetching games...")
+// Page sizes of GamesHandler. The default applies to a ?cursor without a
+// ?limit; a request with neither gets every game.
+const (
+	defaultGamesLimit = 50
+	maxGamesLimit     = 200
+)
+
+// GamesHandler lists games with their goals, a page at a time. Games can be
+// filtered with ?team=arsenal, ?from=2026-10-01&to=2026-10-18 (kick-off dates,
+// both included, or RFC 3339 times) and ?min_goals=3, and their goals with
+// ?player=saka, ?goal_type=penalty and ?disallowed=false. ?sort=oldest
+// reverses the default newest first order. Without ?limit every matching game
+// is returned, which the cleanup keeps to a hundred or so; ?limit=20 pages
+// through them instead, and the response's next_cursor, passed back as
+// ?cursor=, fetches the next page with the same parameters.
+func GamesHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseGamesFilter(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		page, err := store.GetGames(r.Context(), filter)
+		if err != nil {
+			log.Printf("Failed to load games: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to load games")
+			return
+		}
 
-	games, err := db.GetGames()
-	if err != nil {
-		http.Error(w, "Failed to load games", http.StatusInternalServerError)
-		return
+		writeJSON(w, http.StatusOK, models.GamesResponse{
+			Games:      page.Games,
+			NextCursor: page.Next,
+			Status:     http.StatusOK,
+		})
 	}
+}
 
-	response := models.GamesResponse{
-		Games:  games,
-		Status: http.StatusOK,
+// parseGamesFilter reads the GamesHandler query parameters.
+func parseGamesFilter(q url.Values) (db.GamesFilter, error) {
+	f := db.GamesFilter{
+		Team:   q.Get("team"),
+		Player: q.Get("player"),
+		Sort:   db.SortNewest,
+	}
+	if t := q.Get("goal_type"); t != "" {
+		if !models.ValidGoalType(t) {
+			return f, fmt.Errorf("invalid goal_type %q: want open_play, penalty, own_goal or free_kick", t)
+		}
+		f.GoalType = t
+	}
+	if v := q.Get("disallowed"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid disallowed %q: want true or false", v)
+		}
+		f.Disallowed = &b
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := parseGamesTime(v, false)
+		if err != nil {
+			return f, fmt.Errorf("invalid from %q: want a date like 2026-10-18 or an RFC 3339 time", v)
+		}
+		f.From = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := parseGamesTime(v, true)
+		if err != nil {
+			return f, fmt.Errorf("invalid to %q: want a date like 2026-10-18 or an RFC 3339 time", v)
+		}
+		f.To = t
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, fmt.Errorf("invalid range: from must be before to")
+	}
+	if v := q.Get("min_goals"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("invalid min_goals %q: want a number of 0 or more", v)
+		}
+		f.MinGoals = n
 	}
+	if v := q.Get("sort"); v != "" {
+		if v != db.SortNewest && v != db.SortOldest {
+			return f, fmt.Errorf("invalid sort %q: want newest or oldest", v)
+		}
+		f.Sort = v
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxGamesLimit {
+			return f, fmt.Errorf("invalid limit %q: want a number from 1 to %d", v, maxGamesLimit)
+		}
+		f.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, err := db.ParseGamesCursor(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid cursor: pass back the next_cursor of a previous page")
+		}
+		if c.Sort != f.Sort {
+			return f, fmt.Errorf("invalid cursor: it continues a %s first listing, not %s", c.Sort, f.Sort)
+		}
+		f.After = &c
+		if f.Limit == 0 {
+			f.Limit = defaultGamesLimit
+		}
+	}
+	return f, nil
+}
 
-	origin := os.Getenv("CORS_ORIGIN")
+// parseGamesTime reads a from or to parameter. A plain date is midnight UTC,
+// or the midnight after when end is set so that the whole day is included.
+func parseGamesTime(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// GameHandler returns one game with its goals, e.g. GET /api/games/7.
+func GameHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid game id")
+			return
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", origin)
+		game, err := store.Game(r.Context(), id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Game not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load game %d: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load game")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.GameResponse{Game: game, Status: http.StatusOK})
+	}
+}
 
-	w.WriteHeader(http.StatusOK)
+// GoalHandler returns one goal, e.g. GET /api/goals/12.
+func GoalHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid goal id")
+			return
+		}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
+		goal, err := store.Goal(r.Context(), id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Goal not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load goal %d: %v", id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load goal")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.GoalResponse{Goal: goal, Status: http.StatusOK})
 	}
 }
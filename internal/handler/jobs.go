@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"blooters/internal/scheduler"
+)
+
+type JobStatus struct {
+	Name            string    `json:"name"`
+	IntervalSeconds float64   `json:"interval_seconds"`
+	Running         bool      `json:"running"`
+	LastRun         time.Time `json:"last_run"`
+	LastDurationMs  int64     `json:"last_duration_ms"`
+	LastError       string    `json:"last_error,omitempty"`
+	NextRun         time.Time `json:"next_run"`
+}
+
+type JobsResponse struct {
+	Jobs   []JobStatus `json:"jobs"`
+	Status int         `json:"status"`
+}
+
+// JobsHandler reports the schedule and last outcome of the background jobs.
+func JobsHandler(sched *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := JobsResponse{
+			Jobs:   []JobStatus{},
+			Status: http.StatusOK,
+		}
+		for _, st := range sched.Status() {
+			response.Jobs = append(response.Jobs, JobStatus{
+				Name:            st.Name,
+				IntervalSeconds: st.Interval.Seconds(),
+				Running:         st.Running,
+				LastRun:         st.LastRun,
+				LastDurationMs:  st.LastDuration.Milliseconds(),
+				LastError:       st.LastError,
+				NextRun:         st.NextRun,
+			})
+		}
+
+		origin := os.Getenv("CORS_ORIGIN")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"blooters/internal/db"
+	"blooters/internal/models"
+)
+
+// Page sizes of PlayerGoalsHandler.
+const (
+	defaultPlayerGoalsLimit = 100
+	maxPlayerGoalsLimit     = 500
+)
+
+// PlayerGoalsHandler lists the goals of a player, by any spelling, newest
+// first, e.g. GET /api/players/saka/goals?limit=20. Everyone going by that
+// name is included.
+func PlayerGoalsHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := parseLimit(r.URL.Query(), defaultPlayerGoalsLimit, maxPlayerGoalsLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		name := r.PathValue("name")
+		players, goals, err := store.PlayerGoals(r.Context(), name, limit)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Player not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load goals of player %q: %v", name, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load goals")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.PlayerGoalsResponse{Players: players, Goals: goals, Status: http.StatusOK})
+	}
+}
+
+// parseLimit reads ?limit, from 1 to maxLimit, def when absent.
+func parseLimit(q url.Values, def, maxLimit int) (int, error) {
+	v := q.Get("limit")
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxLimit {
+		return 0, fmt.Errorf("invalid limit %q: want a number from 1 to %d", v, maxLimit)
+	}
+	return n, nil
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"blooters/internal/models"
+)
+
+// writeJSON sends v as the JSON response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	origin := os.Getenv("CORS_ORIGIN")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// writeError sends a JSON error body, so API clients always get JSON back.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, models.ErrorResponse{Error: msg, Status: status})
+}
+
+// NotFoundHandler answers API paths that don't exist with the JSON error body
+// of the other endpoints.
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, "Not found")
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"blooters/internal/db"
+	"blooters/internal/models"
+)
+
+// Result counts of SearchHandler.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchHandler finds goals by scorer, teams or title, best match first, e.g.
+// GET /api/search?q=salah+everton. Accents don't matter and the words can be
+// partial, so it can back an autocomplete; ?limit=5 caps the results.
+func SearchHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		text := strings.TrimSpace(q.Get("q"))
+		if text == "" {
+			writeError(w, http.StatusBadRequest, "Missing search, want ?q=<words>")
+			return
+		}
+		limit, err := parseLimit(q, defaultSearchLimit, maxSearchLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		goals, err := store.SearchGoals(r.Context(), text, limit)
+		if errors.Is(err, db.ErrInvalid) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to search goals for %q: %v", text, err)
+			writeError(w, http.StatusInternalServerError, "Failed to search goals")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.SearchResponse{Query: text, Goals: goals, Status: http.StatusOK})
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"blooters/internal/events"
+)
+
+const streamHeartbeat = 20 * time.Second
+
+// StreamHandler pushes game changes to the client as Server-Sent Events.
+// Clients resume after a reconnect with the standard Last-Event-ID header
+// (or a last_event_id query parameter for clients that cannot set headers).
+// Streams end when shutdown is closed so they don't block a graceful shutdown.
+func StreamHandler(broker *events.Broker, shutdown <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+
+		var lastID uint64
+		raw := r.Header.Get("Last-Event-ID")
+		if raw == "" {
+			raw = r.URL.Query().Get("last_event_id")
+		}
+		if raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+			lastID = id
+		}
+
+		ch, backlog := broker.Subscribe(lastID)
+		defer broker.Unsubscribe(ch)
+
+		origin := os.Getenv("CORS_ORIGIN")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.WriteHeader(http.StatusOK)
+
+		for _, ev := range backlog {
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			log.Printf("Streaming not supported: %v", err)
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-shutdown:
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, ev); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev events.Event) error {
+	data := []byte("{}")
+	if ev.Data != nil {
+		var err error
+		data, err = json.Marshal(ev.Data)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blooters/internal/db"
+	"blooters/internal/middleware"
+	"blooters/internal/models"
+)
+
+// TeamsHandler lists the canonical teams and the spellings mapped to them.
+func TeamsHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		teams, err := store.Teams(r.Context())
+		if err != nil {
+			log.Printf("Failed to load teams: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to load teams")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.TeamsResponse{Teams: teams, Status: http.StatusOK})
+	}
+}
+
+type AddAliasRequest struct {
+	Alias string `json:"alias"`
+}
+
+// AddTeamAliasHandler maps another spelling to the team in the path, e.g.
+// POST /api/admin/teams/12/aliases {"alias": "Spurs"}.
+func AddTeamAliasHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid team id")
+			return
+		}
+
+		var req AddAliasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Alias) == "" {
+			writeError(w, http.StatusBadRequest, `Body must be {"alias": "<team name>"}`)
+			return
+		}
+
+		team, err := store.AddTeamAlias(r.Context(), id, req.Alias)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Team not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to add alias %q to team %d: %v", req.Alias, id, err)
+			writeError(w, http.StatusInternalServerError, "Failed to add alias")
+			return
+		}
+
+		log.Printf("%s mapped %q to team %d (%s)", middleware.Actor(r.Context()), req.Alias, team.ID, team.Name)
+		writeJSON(w, http.StatusOK, models.TeamResponse{Team: team, Status: http.StatusOK})
+	}
+}
+
+// TeamGamesHandler lists the games of a team, by slug or any spelling, e.g.
+// GET /api/teams/arsenal/games. It takes the GamesHandler parameters but team.
+func TeamGamesHandler(store *db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Has("team") {
+			writeError(w, http.StatusBadRequest, "The team is given by the path, not ?team")
+			return
+		}
+		filter, err := parseGamesFilter(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		name := r.PathValue("name")
+		team, err := store.TeamByName(r.Context(), name)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Team not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load team %q: %v", name, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load team")
+			return
+		}
+
+		filter.TeamID = team.ID
+		page, err := store.GetGames(r.Context(), filter)
+		if err != nil {
+			log.Printf("Failed to load games of team %d: %v", team.ID, err)
+			writeError(w, http.StatusInternalServerError, "Failed to load games")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.TeamGamesResponse{
+			Team:       team,
+			Games:      page.Games,
+			NextCursor: page.Next,
+			Status:     http.StatusOK,
+		})
+	}
+}
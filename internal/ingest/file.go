@@ -0,0 +1,49 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"blooters/internal/models"
+)
+
+func init() {
+	Register("file", func(path string, _ CursorStore) (GoalSource, error) {
+		if path == "" {
+			return nil, fmt.Errorf("file source needs a path, e.g. file:goals.json")
+		}
+		return &FileSource{path: path}, nil
+	})
+}
+
+// FileSource replays goals from a local JSON file holding an array of
+// models.Goal. It is meant for local development and fixtures; the file is
+// re-read on every fetch so it can be edited while the server runs.
+type FileSource struct {
+	HealthTracker
+	path string
+}
+
+func (s *FileSource) Name() string {
+	return "file/" + s.path
+}
+
+func (s *FileSource) Fetch(ctx context.Context) ([]models.Goal, error) {
+	goals, err := s.read()
+	s.Record(err)
+	return goals, err
+}
+
+func (s *FileSource) read() ([]models.Goal, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+	var goals []models.Goal
+	if err := json.Unmarshal(data, &goals); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file: %w", err)
+	}
+	return goals, nil
+}
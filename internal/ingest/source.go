@@ -0,0 +1,197 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blooters/internal/metrics"
+	"blooters/internal/models"
+)
+
+// GoalSource is a feed of goal posts. Implementations are registered by kind
+// (see Register) and selected at startup with the GOAL_SOURCES variable.
+type GoalSource interface {
+	// Name identifies the source in logs and metric labels, e.g. "reddit/soccer".
+	Name() string
+	// Fetch returns the goals currently visible in the feed.
+	Fetch(ctx context.Context) ([]models.Goal, error)
+	// Health reports the outcome of recent fetches.
+	Health() Health
+}
+
+// Committer is implemented by sources that track how far they have read. The
+// ingestion loop calls Commit only after the fetched goals have been stored,
+// so a failed store is retried from the same position on the next fetch.
+type Committer interface {
+	Commit(ctx context.Context) error
+}
+
+// Rejecter is implemented by sources that drop posts they can't read as a
+// goal. After each Fetch, Rejected returns the posts dropped by it, which the
+// ingestion loop quarantines for review.
+type Rejecter interface {
+	Rejected() []models.ParseFailure
+}
+
+type Health struct {
+	Healthy             bool      `json:"healthy"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastError           string    `json:"last_error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// HealthTracker can be embedded in a GoalSource to implement Health.
+type HealthTracker struct {
+	mu     sync.Mutex
+	health Health
+}
+
+// Record updates the health after a fetch attempt.
+func (t *HealthTracker) Record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.health.Healthy = false
+		t.health.LastError = err.Error()
+		t.health.ConsecutiveFailures++
+		return
+	}
+	t.health.Healthy = true
+	t.health.LastSuccess = time.Now()
+	t.health.LastError = ""
+	t.health.ConsecutiveFailures = 0
+}
+
+func (t *HealthTracker) Health() Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.health
+}
+
+// CursorStore persists how far each source has read, keyed by source name.
+type CursorStore interface {
+	GetCursor(ctx context.Context, source string) (string, error)
+	SetCursor(ctx context.Context, source, cursor string) error
+}
+
+// Factory builds a source from the argument part of a GOAL_SOURCES entry,
+// e.g. "soccer" for "reddit:soccer". Sources that track a read position keep
+// it in cursors.
+type Factory func(arg string, cursors CursorStore) (GoalSource, error)
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a source kind available to SourcesFromSpec. It is meant to be
+// called from the init function of the package implementing the source.
+func Register(kind string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, dup := factories[kind]; dup {
+		panic("ingest: Register called twice for source kind " + kind)
+	}
+	factories[kind] = f
+}
+
+// SourcesFromSpec builds sources from a comma separated list of kind:arg
+// entries, e.g. "reddit:soccer,file:testdata/goals.json".
+func SourcesFromSpec(spec string, cursors CursorStore) ([]GoalSource, error) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	var sources []GoalSource
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, arg, _ := strings.Cut(entry, ":")
+		f, ok := factories[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown goal source %q (known: %s)", kind, strings.Join(knownKinds(), ", "))
+		}
+		src, err := f(arg, cursors)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create goal source %q: %w", entry, err)
+		}
+		sources = append(sources, src)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no goal sources configured")
+	}
+	return sources, nil
+}
+
+// SourcesFromEnv reads GOAL_SOURCES, defaulting to r/soccer.
+func SourcesFromEnv(cursors CursorStore) ([]GoalSource, error) {
+	spec := os.Getenv("GOAL_SOURCES")
+	if spec == "" {
+		spec = "reddit:soccer"
+	}
+	return SourcesFromSpec(spec, cursors)
+}
+
+func knownKinds() []string {
+	kinds := make([]string, 0, len(factories))
+	for k := range factories {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// FetchAll fetches from every source in turn and returns the combined goals,
+// along with the posts the sources rejected. A failing source is logged and
+// counted but does not stop the others.
+func FetchAll(ctx context.Context, sources []GoalSource) ([]models.Goal, []models.ParseFailure) {
+	var all []models.Goal
+	var rejected []models.ParseFailure
+	for _, src := range sources {
+		name := src.Name()
+		goals, err := src.Fetch(ctx)
+		if err != nil {
+			log.Printf("Error fetching goals from %s: %v", name, err)
+			metrics.GoalsFetchCount.WithLabelValues(name, "error").Inc()
+		} else {
+			metrics.GoalsFetchCount.WithLabelValues(name, "success").Inc()
+			metrics.GoalsFetched.WithLabelValues(name).Add(float64(len(goals)))
+			all = append(all, goals...)
+
+			if r, ok := src.(Rejecter); ok {
+				for _, f := range r.Rejected() {
+					f.Source = name
+					metrics.ParseFailures.WithLabelValues(name, f.Reason).Inc()
+					rejected = append(rejected, f)
+				}
+			}
+		}
+
+		if src.Health().Healthy {
+			metrics.GoalSourceHealthy.WithLabelValues(name).Set(1)
+		} else {
+			metrics.GoalSourceHealthy.WithLabelValues(name).Set(0)
+		}
+	}
+	return all, rejected
+}
+
+// CommitAll commits the read position of every source that tracks one.
+func CommitAll(ctx context.Context, sources []GoalSource) {
+	for _, src := range sources {
+		c, ok := src.(Committer)
+		if !ok {
+			continue
+		}
+		if err := c.Commit(ctx); err != nil {
+			log.Printf("Error committing cursor for %s: %v", src.Name(), err)
+		}
+	}
+}
@@ -20,13 +20,33 @@ var (
 	GoalsFetchCount = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "goals_fetch_total",
 		Help: "Total number of goals fetch operations",
-	}, []string{"status"})
+	}, []string{"source", "status"})
+
+	GoalsFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goals_fetched_total",
+		Help: "Total number of goals returned by each goal source",
+	}, []string{"source"})
+
+	ParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "parse_failures_total",
+		Help: "Total number of posts a goal source could not read as a goal, by reason",
+	}, []string{"source", "reason"})
+
+	GoalSourceHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "goal_source_healthy",
+		Help: "Whether the last fetch from a goal source succeeded (1) or failed (0)",
+	}, []string{"source"})
 
 	GoalsStoreCount = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "goals_store_total",
 		Help: "Total number of goals store operations",
 	}, []string{"status"})
 
+	GoalsStored = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goals_stored_total",
+		Help: "Total number of goals handled by store operations, by result (inserted, updated, skipped)",
+	}, []string{"result"})
+
 	MirrorsPopulateCount = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mirrors_populate_total",
 		Help: "Total number of mirrors populate operations",
@@ -36,4 +56,25 @@ var (
 		Name: "remove_old_goals_total",
 		Help: "Total number of remove old goals operations",
 	}, []string{"status"})
+
+	JobRunCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_job_runs_total",
+		Help: "Total number of scheduled job runs by outcome (success, error, skipped)",
+	}, []string{"job", "status"})
+
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduler_job_duration_seconds",
+		Help:    "Duration of scheduled job runs in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"job"})
+
+	JobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scheduler_job_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run of each scheduled job",
+	}, []string{"job"})
+
+	ResponseCacheCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "response_cache_requests_total",
+		Help: "Total number of cacheable requests by outcome (hit, miss, not_modified)",
+	}, []string{"path", "result"})
 )
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"blooters/internal/models"
+)
+
+type actorKey struct{}
+
+// AdminTokens maps a bearer token to the name of the admin holding it.
+type AdminTokens map[string]string
+
+// AdminTokensFromEnv reads ADMIN_TOKENS, a comma separated list of
+// name:token pairs, e.g. "alice:s3cret,bob:hunter2". Without it, admin
+// endpoints reject every request.
+func AdminTokensFromEnv() AdminTokens {
+	tokens := AdminTokens{}
+	for _, pair := range strings.Split(os.Getenv("ADMIN_TOKENS"), ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && name != "" && token != "" {
+			tokens[token] = name
+		}
+	}
+	return tokens
+}
+
+// lookup finds the admin holding token, comparing in constant time.
+func (t AdminTokens) lookup(token string) (string, bool) {
+	for known, name := range t {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// RequireAdmin only lets requests with a known "Authorization: Bearer" token
+// through. The admin's name is available to the handler through Actor.
+func RequireAdmin(tokens AdminTokens, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		name, known := tokens.lookup(strings.TrimSpace(token))
+		if !ok || !known {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:  "admin token required",
+				Status: http.StatusUnauthorized,
+			})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey{}, name)))
+	})
+}
+
+// Actor returns the name of the admin making the request, or "" outside of
+// RequireAdmin.
+func Actor(ctx context.Context) string {
+	name, _ := ctx.Value(actorKey{}).(string)
+	return name
+}
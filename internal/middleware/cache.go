@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"blooters/internal/events"
+	"blooters/internal/metrics"
+)
+
+// ResponseCache keeps the encoded successful responses of a GET endpoint in
+// memory, keyed by query parameters, and answers conditional requests from
+// them. Entries go when Invalidate is called, on any broker event once Watch
+// runs, or after the TTL. Every write through db.Store that changes the games
+// publishes an event once it commits.
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu       sync.Mutex
+	entries  map[string]cachedResponse
+	gen      uint64    // bumped by Invalidate, so a response built before it isn't stored
+	modified time.Time // when the data last changed, as far as the cache knows
+}
+
+type cachedResponse struct {
+	header   http.Header
+	body     []byte
+	etag     string
+	modified time.Time
+	expires  time.Time
+}
+
+// NewResponseCache returns a cache that keeps responses for ttl, and at most
+// maxEntries different queries.
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	return &ResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cachedResponse),
+		modified:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Invalidate drops every cached response. Last-Modified has whole seconds, so
+// it moves at least a second past the previous value: a client that cached a
+// response earlier in the same second must not get a 304 for the new one.
+func (c *ResponseCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+	now := time.Now().UTC().Truncate(time.Second)
+	if !now.After(c.modified) {
+		now = c.modified.Add(time.Second)
+	}
+	c.modified = now
+}
+
+// Watch invalidates the cache on every event published to b, until b closes.
+// It blocks, so run it in its own goroutine.
+func (c *ResponseCache) Watch(b *events.Broker) {
+	for {
+		ch, _ := b.Subscribe(0)
+		seen := false
+		for range ch {
+			seen = true
+			c.Invalidate()
+		}
+		// Dropped for falling behind a burst of events, which leaves some
+		// unseen, or the broker closed, which hands out closed channels
+		c.Invalidate()
+		if !seen {
+			return
+		}
+	}
+}
+
+// Handler serves GET requests to next from the cache, with a strong ETag (the
+// SHA-256 of the body) and Last-Modified, and answers 304 Not Modified to
+// If-None-Match and If-Modified-Since when the response is unchanged. Only
+// 200 responses are cached.
+func (c *ResponseCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.URL.Query().Encode()
+		result := "hit"
+		resp, ok := c.get(key)
+		if !ok {
+			result = "miss"
+			c.mu.Lock()
+			gen, modified := c.gen, c.modified
+			c.mu.Unlock()
+
+			rec := &recorder{header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.status != http.StatusOK {
+				copyHeader(w.Header(), rec.header)
+				w.WriteHeader(rec.status)
+				w.Write(rec.body.Bytes())
+				return
+			}
+
+			sum := sha256.Sum256(rec.body.Bytes())
+			resp = cachedResponse{
+				header:   rec.header,
+				body:     rec.body.Bytes(),
+				etag:     `"` + hex.EncodeToString(sum[:]) + `"`,
+				modified: modified,
+				expires:  time.Now().Add(c.ttl),
+			}
+			c.put(key, gen, resp)
+		}
+
+		copyHeader(w.Header(), resp.header)
+		w.Header().Set("ETag", resp.etag)
+		w.Header().Set("Last-Modified", resp.modified.Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if notModified(r, resp) {
+			metrics.ResponseCacheCount.WithLabelValues(r.URL.Path, "not_modified").Inc()
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		metrics.ResponseCacheCount.WithLabelValues(r.URL.Path, result).Inc()
+		w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodHead {
+			w.Write(resp.body)
+		}
+	})
+}
+
+func (c *ResponseCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.entries[key]
+	if ok && time.Now().After(resp.expires) {
+		delete(c.entries, key)
+		return resp, false
+	}
+	return resp, ok
+}
+
+// put stores resp unless the cache was invalidated since gen, when it may have
+// been built from data that changed meanwhile.
+func (c *ResponseCache) put(key string, gen uint64, resp cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if len(c.entries) >= c.maxEntries {
+		// Many different queries; start over rather than track usage
+		clear(c.entries)
+	}
+	c.entries[key] = resp
+}
+
+// notModified tells whether the client's copy of resp is current. As in RFC
+// 9110, If-Modified-Since only counts without If-None-Match.
+func notModified(r *http.Request, resp cachedResponse) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			// Weak comparison, as If-None-Match calls for
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == resp.etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !resp.modified.After(t)
+	}
+	return false
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		dst[k] = slices.Clone(v)
+	}
+}
+
+// recorder buffers a response so that it can be cached before it is sent.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) { r.status = status }
+
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
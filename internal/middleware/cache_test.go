@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"blooters/internal/events"
+)
+
+func TestResponseCache(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"calls":%d}`, calls)
+	})
+	c := NewResponseCache(time.Minute, 10)
+	h := c.Handler(next)
+
+	get := func(url string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/api/games?a=1&b=2")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Last-Modified") == "" {
+		t.Fatalf("first response = %d, ETag %q, Last-Modified %q", first.Code, etag, first.Header().Get("Last-Modified"))
+	}
+
+	// Same query in another order: served from the cache
+	if rec := get("/api/games?b=2&a=1"); rec.Body.String() != first.Body.String() || rec.Header().Get("ETag") != etag || calls != 1 {
+		t.Errorf("cached response = %q (ETag %q) after %d calls, want %q (ETag %q) after 1",
+			rec.Body.String(), rec.Header().Get("ETag"), calls, first.Body.String(), etag)
+	}
+	if rec := get("/api/games?a=1&b=2", "If-None-Match", `"other", `+etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("If-None-Match with the ETag = %d %q, want 304 and no body", rec.Code, rec.Body.String())
+	}
+	if rec := get("/api/games?a=1&b=2", "If-None-Match", `"other"`); rec.Code != http.StatusOK {
+		t.Errorf("If-None-Match with another ETag = %d, want 200", rec.Code)
+	}
+	if rec := get("/api/games?a=1&b=2", "If-Modified-Since", first.Header().Get("Last-Modified")); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since Last-Modified = %d, want 304", rec.Code)
+	}
+
+	// Invalidating within the same second still moves Last-Modified on
+	lastModified := first.Header().Get("Last-Modified")
+	c.Invalidate()
+	if rec := get("/api/games?a=1&b=2", "If-Modified-Since", lastModified); rec.Code != http.StatusOK || rec.Header().Get("Last-Modified") == lastModified {
+		t.Errorf("If-Modified-Since the old Last-Modified after Invalidate = %d (Last-Modified %q), want 200 and a later one",
+			rec.Code, rec.Header().Get("Last-Modified"))
+	}
+	first = get("/api/games?a=1&b=2")
+	etag = first.Header().Get("ETag")
+
+	// Errors aren't cached
+	get("/api/games?fail=1")
+	if rec := get("/api/games?fail=1"); rec.Code != http.StatusBadRequest || calls != 4 {
+		t.Errorf("error response = %d after %d calls, want 400 after 4", rec.Code, calls)
+	}
+
+	b := events.NewBroker(8)
+	done := make(chan struct{})
+	go func() {
+		c.Watch(b)
+		close(done)
+	}()
+	// Wait for Watch to subscribe
+	for c.lenEntries() > 0 {
+		b.Publish(events.TypeGoal, nil)
+		time.Sleep(time.Millisecond)
+	}
+	rec := get("/api/games?a=1&b=2")
+	if rec.Header().Get("ETag") == etag || rec.Body.String() == first.Body.String() {
+		t.Errorf("response after an event = %q (ETag %q), want a fresh one", rec.Body.String(), rec.Header().Get("ETag"))
+	}
+	if rec := get("/api/games?a=1&b=2", "If-None-Match", etag); rec.Code != http.StatusOK {
+		t.Errorf("If-None-Match with the old ETag = %d, want 200", rec.Code)
+	}
+
+	b.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch didn't return after the broker closed")
+	}
+}
+
+func (c *ResponseCache) lenEntries() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -27,11 +27,22 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// previewLimit bounds how much of the response body is kept for logging, so
+// long-lived streams don't grow the buffer forever.
+const previewLimit = 200
+
 func (rw *responseWriter) Write(b []byte) (int, error) {
-	rw.body.Write(b)
+	if room := previewLimit + 1 - rw.body.Len(); room > 0 {
+		rw.body.Write(b[:min(room, len(b))])
+	}
 	return rw.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (Flush, deadlines).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := uuid.New().String()
@@ -56,7 +67,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			"status":           rw.statusCode,
 			"latency_ms":       duration.Milliseconds(),
 			"request_preview":  truncate(requestBody.String(), 8000),
-			"response_preview": truncate(rw.body.String(), 200),
+			"response_preview": truncate(rw.body.String(), previewLimit),
 		}).Info("completed request")
 
 		// Record metrics
@@ -1,34 +1,251 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Goal struct {
-	ID          int    `json:"id"`
-	GameID      int    `json:"game_id"`
-	Description string `json:"description"`
-	HomeTeam    string `json:"home_team"`
-	AwayTeam    string `json:"away_team"`
-	Goalscorer  string `json:"goalscorer"`
-	Minute      string `json:"minute"`
-	Url         string `json:"url"`
-	RedditURL   string `json:"reddit_url"`
-	Mirrors     string `json:"mirrors"`
-	HomeScore   int    `json:"home_score"`
-	AwayScore   int    `json:"away_score"`
-	Away        bool   `json:"away"` // true if goalscorer plays for away team
+	ID           int       `json:"id"`
+	GameID       int       `json:"game_id"`
+	Description  string    `json:"description"`
+	HomeTeam     string    `json:"home_team"`
+	AwayTeam     string    `json:"away_team"`
+	Goalscorer   string    `json:"goalscorer"`
+	PlayerID     int       `json:"player_id,omitempty"` // canonical player, 0 if the scorer is unknown
+	Minute       string    `json:"minute"`
+	Url          string    `json:"url"`
+	RedditURL    string    `json:"reddit_url"`
+	MirrorsURL   string    `json:"mirrors_url"` // the mirrors comment on the post
+	Mirrors      []Mirror  `json:"mirrors"`
+	HomeScore    int       `json:"home_score"`
+	AwayScore    int       `json:"away_score"`
+	Away         bool      `json:"away"` // true if goalscorer plays for away team
+	Side         string    `json:"side"` // one of the Side* constants
+	PostedAt     time.Time `json:"posted_at"`
+	Type         string    `json:"type"`                    // one of the GoalType* constants
+	MinuteBase   int       `json:"minute_base"`             // 90 for "90+3", 0 if unknown
+	MinuteAdded  int       `json:"minute_added"`            // 3 for "90+3"
+	Disallowed   bool      `json:"disallowed"`              // ruled out, e.g. by VAR
+	LockedFields []string  `json:"locked_fields,omitempty"` // corrected by hand, kept as is by ingestion
+}
+
+// Goal types
+const (
+	GoalTypeOpenPlay = "open_play"
+	GoalTypePenalty  = "penalty"
+	GoalTypeOwnGoal  = "own_goal"
+	GoalTypeFreeKick = "free_kick"
+)
+
+// Which side scored a goal. SideUnknown is stored rather than a guess when
+// neither the title nor the previous score tells.
+const (
+	SideHome    = "home"
+	SideAway    = "away"
+	SideUnknown = "unknown"
+)
+
+// ValidGoalType reports whether t is one of the GoalType* constants.
+func ValidGoalType(t string) bool {
+	switch t {
+	case GoalTypeOpenPlay, GoalTypePenalty, GoalTypeOwnGoal, GoalTypeFreeKick:
+		return true
+	}
+	return false
 }
 
 type Game struct {
-	ID        int       `json:"id"`
-	HomeTeam  string    `json:"home_team"`
-	AwayTeam  string    `json:"away_team"`
-	HomeScore int       `json:"home_score"`
-	AwayScore int       `json:"away_score"`
-	Goals     []Goal    `json:"goals"`
-	Timestamp time.Time `json:"timestamp"`
+	ID           int       `json:"id"`
+	MatchID      string    `json:"match_id"` // stable identifier, e.g. 2026-10-18-arsenal-chelsea
+	HomeTeam     string    `json:"home_team"`
+	AwayTeam     string    `json:"away_team"`
+	HomeTeamID   int       `json:"home_team_id"`
+	AwayTeamID   int       `json:"away_team_id"`
+	HomeScore    int       `json:"home_score"`
+	AwayScore    int       `json:"away_score"`
+	Goals        []Goal    `json:"goals"`
+	StartedAt    time.Time `json:"started_at"` // when the first goal of the match was posted
+	Timestamp    time.Time `json:"timestamp"`
+	LockedFields []string  `json:"locked_fields,omitempty"` // corrected by hand, kept as is by ingestion
 }
 
-type GamesResponse struct {
-	Games  []Game `json:"games"`
+// Team is a canonical club. Aliases are the normalized spellings that map to it.
+type Team struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Slug    string   `json:"slug"`
+	Aliases []string `json:"aliases"`
+}
+
+type TeamsResponse struct {
+	Teams  []Team `json:"teams"`
 	Status int    `json:"status"`
 }
+
+// Player is a canonical goalscorer with the spellings mapped to them.
+type Player struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+type TeamGamesResponse struct {
+	Team       Team   `json:"team"`
+	Games      []Game `json:"games"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Status     int    `json:"status"`
+}
+
+type PlayerGoalsResponse struct {
+	// Players are all the players going by the requested name.
+	Players []Player `json:"players"`
+	Goals   []Goal   `json:"goals"`
+	Status  int      `json:"status"`
+}
+
+type TeamResponse struct {
+	Team   Team `json:"team"`
+	Status int  `json:"status"`
+}
+
+// ParseFailure is a post a source could not read as a goal, kept so that it
+// can be reviewed, re-parsed after a parser fix or promoted by hand.
+type ParseFailure struct {
+	ID         int        `json:"id"`
+	Source     string     `json:"source"`
+	Title      string     `json:"title"`
+	URL        string     `json:"url"`
+	Permalink  string     `json:"permalink"`
+	Reason     string     `json:"reason"`
+	PostedAt   time.Time  `json:"posted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	GoalID     int        `json:"goal_id,omitempty"` // the goal it was promoted to
+	PromotedAt *time.Time `json:"promoted_at,omitempty"`
+}
+
+type ParseFailuresResponse struct {
+	Failures []ParseFailure `json:"failures"`
+	Status   int            `json:"status"`
+}
+
+type ParseFailureResponse struct {
+	Failure ParseFailure `json:"failure"`
+	Status  int          `json:"status"`
+}
+
+// ReparseResponse reports a re-parse of the pending parse failures.
+type ReparseResponse struct {
+	Checked  int            `json:"checked"`
+	Promoted []ParseFailure `json:"promoted"`
+	Status   int            `json:"status"`
+}
+
+// AuditEntry records one manual correction: who made it and the row before
+// and after. Before is null for rows created and After for rows deleted.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"` // update, reassign or merge
+	Entity    string          `json:"entity"` // goal or game
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Status  int          `json:"status"`
+}
+
+type GoalResponse struct {
+	Goal   Goal `json:"goal"`
+	Status int  `json:"status"`
+}
+
+type GameResponse struct {
+	Game   Game `json:"game"`
+	Status int  `json:"status"`
+}
+
+// ErrorResponse is the body of every JSON error.
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+type SearchResponse struct {
+	Query  string `json:"query"`
+	Goals  []Goal `json:"goals"`
+	Status int    `json:"status"`
+}
+
+// Tombstone is a game or goal that was deleted.
+type Tombstone struct {
+	Entity string `json:"entity"` // "game" or "goal"
+	ID     int    `json:"id"`
+}
+
+type ChangesResponse struct {
+	// Revision is the since of the next call.
+	Revision int64       `json:"revision"`
+	Games    []Game      `json:"games"`
+	Goals    []Goal      `json:"goals"`
+	Deleted  []Tombstone `json:"deleted"`
+	// More tells that there are more changes after Revision already.
+	More   bool `json:"more"`
+	Status int  `json:"status"`
+}
+
+type GamesResponse struct {
+	Games []Game `json:"games"`
+	// NextCursor fetches the next page as ?cursor=, empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+	Status     int    `json:"status"`
+}
+
+// Mirror is another copy or angle of a goal video, linked from the mirrors
+// comment of the post.
+type Mirror struct {
+	Host  string `json:"host"`  // e.g. streamable.com
+	Label string `json:"label"` // as written next to the link, e.g. "alternate angle"
+	URL   string `json:"url"`
+}
+
+// ScoreUpdate is pushed on the games stream when a game's score changes
+type ScoreUpdate struct {
+	GameID    int `json:"game_id"`
+	HomeScore int `json:"home_score"`
+	AwayScore int `json:"away_score"`
+}
+
+// Correction is pushed on the games stream when a goal or game is corrected
+// by hand. GameIDs are the games whose listing changed: the goal's game and,
+// for a reassignment, the game it moved to; for a merge, the merged game,
+// which is gone, and the game merged into.
+type Correction struct {
+	Entity   string `json:"entity"` // "goal" or "game"
+	EntityID int    `json:"entity_id"`
+	Action   string `json:"action"` // update, reassign or merge, as in the audit log
+	GameIDs  []int  `json:"game_ids"`
+}
+
+// Change is pushed on the games stream after a write that changes the games
+// but has no event of its own. Clients refetch.
+type Change struct {
+	Reason string `json:"reason"` // one of the Change* constants
+}
+
+// Change reasons.
+const (
+	ChangeCleanup = "cleanup" // old games removed
+	ChangeTeams   = "teams"   // team aliases or names changed, moving or renaming games
+)
+
+// MirrorsUpdate is pushed on the games stream when a goal gets its mirrors
+type MirrorsUpdate struct {
+	GoalID     int      `json:"goal_id"`
+	MirrorsURL string   `json:"mirrors_url"`
+	Mirrors    []Mirror `json:"mirrors"`
+}
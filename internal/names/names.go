@@ -0,0 +1,109 @@
+package names
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// teamNoise are tokens that don't tell teams apart, e.g. "FC" in "Chelsea FC".
+var teamNoise = map[string]bool{
+	"fc":  true,
+	"afc": true,
+	"cf":  true,
+}
+
+// Fold lowercases s, strips accents and replaces punctuation with spaces, so
+// "Atlético Madrid" and "atletico-madrid" compare equal.
+func Fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent, drop it
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		case r == '\'' || r == '’':
+			// "Nott'm" and "Nottm" are the same word
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// TeamKey is the comparison key for a team name as typed in a post title.
+func TeamKey(name string) string {
+	fields := strings.Fields(Fold(name))
+	kept := fields[:0]
+	for _, f := range fields {
+		if !teamNoise[f] {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(kept, " ")
+}
+
+// Slug turns a key into a URL-friendly identifier, e.g. "man utd" -> "man-utd".
+func Slug(key string) string {
+	return strings.ReplaceAll(Fold(key), " ", "-")
+}
+
+// scorerModifiers are the markers titles put after a scorer's name, matched
+// case-insensitively as whole trailing words, with or without parentheses.
+var scorerModifiers = []string{"pen", "pen.", "p", "penalty", "og", "o.g.", "own goal", "own-goal", "fk", "free kick", "free-kick"}
+
+// PlayerName cleans up a goalscorer as typed in a title: trailing penalty and
+// own goal markers are removed and whitespace is collapsed. Casing and accents
+// are kept as typed, except that names typed in all lower or all upper case
+// are capitalized word by word ("van dijk" -> "Van Dijk", "N'GOLO" -> "N'Golo").
+func PlayerName(raw string) string {
+	name := strings.Join(strings.Fields(raw), " ")
+	for changed := true; changed; {
+		changed = false
+		lower := strings.ToLower(name)
+		for _, m := range scorerModifiers {
+			for _, suffix := range []string{" (" + m + ")", " " + m, "(" + m + ")"} {
+				if strings.HasSuffix(lower, suffix) && len(lower) > len(suffix) {
+					name = strings.TrimSpace(name[:len(name)-len(suffix)])
+					changed = true
+					break
+				}
+			}
+			if changed {
+				break
+			}
+		}
+	}
+	name = strings.TrimRight(name, " ,-")
+
+	if name != strings.ToLower(name) && name != strings.ToUpper(name) {
+		return name
+	}
+	return capitalize(name)
+}
+
+// capitalize upper-cases the first letter of every word and of every part
+// after a hyphen or apostrophe, lower-casing the rest.
+func capitalize(s string) string {
+	var b strings.Builder
+	start := true
+	for _, r := range s {
+		if start && unicode.IsLetter(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		start = r == ' ' || r == '-' || r == '\'' || r == '’' || r == '.'
+	}
+	return b.String()
+}
+
+// PlayerKey is the comparison key for a player name.
+func PlayerKey(name string) string {
+	return Fold(PlayerName(name))
+}
@@ -0,0 +1,65 @@
+package names
+
+import "testing"
+
+func TestTeamKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Chelsea", "chelsea"},
+		{"Chelsea FC", "chelsea"},
+		{"AFC Bournemouth", "bournemouth"},
+		{"Atlético Madrid", "atletico madrid"},
+		{"Nott'm Forest", "nottm forest"},
+		{"  Brighton & Hove   Albion ", "brighton hove albion"},
+		{"Paris Saint-Germain", "paris saint germain"},
+		{"FC", "fc"},
+	}
+
+	for _, tt := range tests {
+		if got := TeamKey(tt.name); got != tt.want {
+			t.Errorf("TeamKey(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	if got := Slug("Bayern München"); got != "bayern-munchen" {
+		t.Errorf("Slug(%q) = %q, want %q", "Bayern München", got, "bayern-munchen")
+	}
+}
+
+func TestPlayerName(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"Virgil van Dijk", "Virgil van Dijk"},
+		{"Van Dijk", "Van Dijk"},
+		{"McTominay", "McTominay"},
+		{"N'Golo Kanté", "N'Golo Kanté"},
+		{"De Bruyne (pen)", "De Bruyne"},
+		{"Kevin De Bruyne pen", "Kevin De Bruyne"},
+		{"Maguire (OG)", "Maguire"},
+		{"Maguire own goal", "Maguire"},
+		{"Haaland (P)", "Haaland"},
+		{"  Erling   Haaland ", "Erling Haaland"},
+		{"van dijk", "Van Dijk"},
+		{"ÓSCAR MINGUEZA", "Óscar Mingueza"},
+		{"n'golo kanté", "N'Golo Kanté"},
+		{"Og", "Og"},
+	}
+
+	for _, tt := range tests {
+		if got := PlayerName(tt.raw); got != tt.want {
+			t.Errorf("PlayerName(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestPlayerKey(t *testing.T) {
+	if a, b := PlayerKey("Kanté"), PlayerKey("kante (pen)"); a != b {
+		t.Errorf("PlayerKey mismatch: %q vs %q", a, b)
+	}
+}
@@ -0,0 +1,307 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTokenURL  = "https://www.reddit.com/api/v1/access_token"
+	oauthBaseURL     = "https://oauth.reddit.com"
+	publicBaseURL    = "https://www.reddit.com"
+	defaultUserAgent = "blooters/1.0 (goal scraper)"
+
+	// commentReserve is the part of the rate-limit budget that comment
+	// requests leave untouched, so the goal listing can always be polled.
+	commentReserve = 10
+)
+
+// Config configures a Client. Without a ClientID the client falls back to the
+// unauthenticated www.reddit.com endpoints.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// Username and Password select the script-app password grant; when empty
+	// the app-only client_credentials grant is used instead.
+	Username  string
+	Password  string
+	UserAgent string
+	// TokenURL and BaseURL override the Reddit endpoints, e.g. for a local fake server.
+	TokenURL   string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// ConfigFromEnv reads the REDDIT_* environment variables.
+func ConfigFromEnv() Config {
+	return Config{
+		ClientID:     os.Getenv("REDDIT_CLIENT_ID"),
+		ClientSecret: os.Getenv("REDDIT_CLIENT_SECRET"),
+		Username:     os.Getenv("REDDIT_USERNAME"),
+		Password:     os.Getenv("REDDIT_PASSWORD"),
+		UserAgent:    os.Getenv("REDDIT_USER_AGENT"),
+		TokenURL:     os.Getenv("REDDIT_TOKEN_URL"),
+		BaseURL:      os.Getenv("REDDIT_BASE_URL"),
+	}
+}
+
+// Client talks to the Reddit API. All requests made through one Client share
+// a single rate-limit budget taken from Reddit's X-Ratelimit-* headers.
+type Client struct {
+	cfg     Config
+	http    *http.Client
+	limiter *rateLimiter
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaultUserAgent
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = DefaultTokenURL
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = publicBaseURL
+		if cfg.ClientID != "" {
+			cfg.BaseURL = oauthBaseURL
+		}
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	// Until Reddit tells us the budget, keep to the old one request per second.
+	minInterval := time.Second
+	if cfg.ClientID != "" {
+		minInterval = 0
+	}
+
+	return &Client{
+		cfg:     cfg,
+		http:    httpClient,
+		limiter: &rateLimiter{remaining: -1, minInterval: minInterval},
+	}
+}
+
+var defaultClient = sync.OnceValue(func() *Client {
+	return NewClient(ConfigFromEnv())
+})
+
+// DefaultClient returns the process-wide client configured from the environment.
+func DefaultClient() *Client {
+	return defaultClient()
+}
+
+// Listing fetches a page of a subreddit's newest posts.
+func (c *Client) Listing(ctx context.Context, subreddit, after string) (RedditResponse, error) {
+	var listing RedditResponse
+	path := "/r/" + url.PathEscape(subreddit) + "/new.json?limit=100"
+	if after != "" {
+		path += "&after=" + url.QueryEscape(after)
+	}
+	err := c.get(ctx, path, 0, &listing)
+	return listing, err
+}
+
+// Comments fetches a post and its comment tree. permalink may be a path or a
+// full reddit.com URL. Comment requests are lower priority than listings and
+// wait while the remaining budget is below commentReserve.
+func (c *Client) Comments(ctx context.Context, permalink string, v any) error {
+	if u, err := url.Parse(permalink); err == nil && u.Path != "" {
+		permalink = u.Path
+	}
+	path := strings.TrimSuffix(permalink, "/") + "/.json"
+	return c.get(ctx, path, commentReserve, v)
+}
+
+func (c *Client) get(ctx context.Context, path string, reserve float64, v any) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, reserve); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", c.cfg.BaseURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+		if c.cfg.ClientID != "" {
+			token, err := c.accessToken(ctx)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to fetch from Reddit: %w", err)
+		}
+		c.limiter.update(resp.Header)
+
+		if resp.StatusCode == http.StatusUnauthorized && c.cfg.ClientID != "" && attempt == 0 {
+			// The token was revoked or expired early; get a new one and retry once.
+			resp.Body.Close()
+			c.invalidateToken()
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("reddit API returned status %d for %s", resp.StatusCode, path)
+		}
+		if err := json.Unmarshal(body, v); err != nil {
+			return fmt.Errorf("failed to parse JSON response: %w", err)
+		}
+		return nil
+	}
+}
+
+// accessToken returns a cached bearer token, fetching a new one when it is
+// missing or about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Until(c.tokenExpiry) > time.Minute {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	if c.cfg.Username != "" {
+		form.Set("grant_type", "password")
+		form.Set("username", c.cfg.Username)
+		form.Set("password", c.cfg.Password)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	// Reddit reports bad credentials as 200 with an error field.
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no token: %s", tok.Error)
+	}
+
+	c.token = tok.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+func (c *Client) invalidateToken() {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.token = ""
+}
+
+// rateLimiter spreads requests over Reddit's rate-limit window. After each
+// response it knows how many requests remain and when the window resets;
+// requests are then spaced so the budget lasts until the reset.
+type rateLimiter struct {
+	mu          sync.Mutex
+	remaining   float64 // -1 until the first response carries the headers
+	reset       time.Time
+	next        time.Time // earliest start of the next request
+	minInterval time.Duration
+}
+
+// wait blocks until a request may be sent. reserve is the part of the
+// remaining budget this request is not allowed to use.
+func (l *rateLimiter) wait(ctx context.Context, reserve float64) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		known := l.remaining >= 0 && now.Before(l.reset)
+
+		var delay time.Duration
+		switch {
+		case known && l.remaining-reserve < 1:
+			// Out of budget for this priority: wait for the window to reset.
+			delay = l.reset.Sub(now)
+		default:
+			delay = max(l.next.Sub(now), 0)
+		}
+
+		if delay <= 0 {
+			interval := l.minInterval
+			if known {
+				interval = max(interval, l.reset.Sub(now)/time.Duration(l.remaining))
+				l.remaining--
+			}
+			l.next = now.Add(interval)
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// update records the budget reported by a response.
+func (l *rateLimiter) update(h http.Header) {
+	remaining, err := strconv.ParseFloat(h.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	resetSecs, err := strconv.ParseFloat(h.Get("X-Ratelimit-Reset"), 64)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.reset = time.Now().Add(time.Duration(resetSecs * float64(time.Second)))
+}
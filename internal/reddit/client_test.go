@@ -0,0 +1,121 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeReddit serves a token endpoint and an empty listing, checking that
+// API calls carry the most recently issued bearer token.
+type fakeReddit struct {
+	tokensIssued atomic.Int32
+	revokeFirst  bool // answer the first API call with 401
+	apiCalls     atomic.Int32
+	remaining    string
+	reset        string
+}
+
+func (f *fakeReddit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/v1/access_token":
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "id" || secret != "secret" || r.FormValue("grant_type") != "password" || r.FormValue("username") != "bot" {
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		n := f.tokensIssued.Add(1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, n)
+	default:
+		call := f.apiCalls.Add(1)
+		want := fmt.Sprintf("Bearer token-%d", f.tokensIssued.Load())
+		if r.Header.Get("Authorization") != want || (f.revokeFirst && call == 1) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Ratelimit-Remaining", f.remaining)
+		w.Header().Set("X-Ratelimit-Reset", f.reset)
+		w.Write([]byte(`{"kind": "Listing", "data": {"after": null, "children": []}}`))
+	}
+}
+
+func newTestClient(srv *httptest.Server) *Client {
+	return NewClient(Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Username:     "bot",
+		Password:     "hunter2",
+		TokenURL:     srv.URL + "/api/v1/access_token",
+		BaseURL:      srv.URL,
+	})
+}
+
+func TestClientTokenRefresh(t *testing.T) {
+	fake := &fakeReddit{revokeFirst: true, remaining: "100", reset: "600"}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if _, err := c.Listing(context.Background(), "soccer", ""); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	if got := fake.tokensIssued.Load(); got != 2 {
+		t.Errorf("tokens issued = %d, want 2 (initial + refresh after 401)", got)
+	}
+
+	// The refreshed token is cached for later requests.
+	if _, err := c.Listing(context.Background(), "soccer", ""); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	if got := fake.tokensIssued.Load(); got != 2 {
+		t.Errorf("tokens issued = %d, want the cached token to be reused", got)
+	}
+}
+
+func TestClientWaitsForRateLimitReset(t *testing.T) {
+	fake := &fakeReddit{remaining: "0", reset: "1"}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if _, err := c.Listing(context.Background(), "soccer", ""); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+
+	start := time.Now()
+	if _, err := c.Listing(context.Background(), "soccer", ""); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Errorf("second request sent after %v, want it held until the window reset", waited)
+	}
+
+	// A cancelled context gives up instead of waiting for the budget.
+	fake.remaining = "0"
+	fake.reset = "60"
+	if _, err := c.Listing(context.Background(), "soccer", ""); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Listing(ctx, "soccer", ""); err == nil {
+		t.Error("Listing() with exhausted budget and expiring context succeeded, want error")
+	}
+}
+
+func TestClientCommentsReserveBudget(t *testing.T) {
+	l := &rateLimiter{remaining: 5, reset: time.Now().Add(time.Minute)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, commentReserve); err == nil {
+		t.Error("comment request allowed into the reserved budget")
+	}
+	if err := l.wait(context.Background(), 0); err != nil {
+		t.Errorf("listing request blocked by the comment reserve: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"blooters/internal/db"
+	"blooters/internal/models"
+)
+
+// failureGoal reads the goal of a quarantined post. title, when not empty, is
+// parsed in place of the post's own, e.g. a retyped title for a post the
+// parser can't read as posted.
+func failureGoal(f models.ParseFailure, title string) (models.Goal, error) {
+	if title == "" {
+		title = f.Title
+	}
+	goal, err := ParseGoalFromTitle(title, f.URL, f.Permalink)
+	if err != nil {
+		return goal, err
+	}
+	goal.Description = f.Title
+	goal.PostedAt = f.PostedAt
+	return goal, nil
+}
+
+// Promote stores the goal of a quarantined post, see failureGoal. A title that
+// still doesn't parse is returned as a *ParseError.
+func Promote(ctx context.Context, store *db.Store, f models.ParseFailure, title string) (models.ParseFailure, error) {
+	goal, err := failureGoal(f, title)
+	if err != nil {
+		return f, err
+	}
+	return store.PromoteParseFailure(ctx, f.ID, goal)
+}
+
+// Reparse runs the parser again over the pending failures from reddit
+// sources, e.g. after a parser fix. Posts that now parse are promoted to
+// goals, the others get their reason refreshed. It returns how many failures
+// were checked and those promoted.
+func Reparse(ctx context.Context, store *db.Store) (int, []models.ParseFailure, error) {
+	failures, err := store.ListParseFailures(ctx, db.ParseFailuresFilter{})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	checked := 0
+	promoted := []models.ParseFailure{}
+	for _, f := range failures {
+		if !strings.HasPrefix(f.Source, "reddit/") {
+			continue
+		}
+		checked++
+
+		goal, err := failureGoal(f, "")
+		if err != nil {
+			if reason := failureReason(err); reason != f.Reason {
+				if err := store.SetParseFailureReason(ctx, f.ID, reason); err != nil {
+					return checked, promoted, err
+				}
+			}
+			continue
+		}
+		p, err := store.PromoteParseFailure(ctx, f.ID, goal)
+		if err != nil {
+			return checked, promoted, fmt.Errorf("failed to promote parse failure %d: %w", f.ID, err)
+		}
+		promoted = append(promoted, p)
+	}
+	return checked, promoted, nil
+}
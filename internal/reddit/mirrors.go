@@ -0,0 +1,164 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"blooters/internal/models"
+)
+
+// mirrorsHeading starts the AutoModerator comment that mirrors are posted under.
+const mirrorsHeading = "Mirrors / Alternative Angles"
+
+// maxLabelLen bounds a mirror label taken from the text around a link.
+const maxLabelLen = 80
+
+// UnmarshalJSON accepts the empty string reddit sends in place of the replies
+// of a comment that has none.
+func (c *Comments) UnmarshalJSON(b []byte) error {
+	if string(b) == `""` {
+		*c = Comments{}
+		return nil
+	}
+	type plain Comments
+	return json.Unmarshal(b, (*plain)(c))
+}
+
+// findMirrorsComment returns the AutoModerator mirrors comment among the
+// top-level comments of a post.
+func findMirrorsComment(comments []Comment) (Comment, bool) {
+	for _, c := range comments {
+		if c.Kind == "t1" && c.Data.Author == "AutoModerator" && strings.Contains(c.Data.Body, mirrorsHeading) {
+			return c, true
+		}
+	}
+	return Comment{}, false
+}
+
+// commentMirrors collects the mirrors in the body of the mirrors comment and
+// the whole tree of replies under it, in thread order, without repeating a
+// link or primary, the URL of the post itself. Replies reddit folds away
+// behind "load more comments" are not followed.
+func commentMirrors(c Comment, primary string) []models.Mirror {
+	seen := map[string]bool{primary: true}
+	var mirrors []models.Mirror
+	var walk func(c Comment)
+	walk = func(c Comment) {
+		if c.Kind != "t1" {
+			return
+		}
+		for _, m := range parseMirrors(c.Data.Body) {
+			if !seen[m.URL] {
+				seen[m.URL] = true
+				mirrors = append(mirrors, m)
+			}
+		}
+		if c.Data.Replies != nil {
+			for _, r := range c.Data.Replies.Data.Children {
+				walk(r)
+			}
+		}
+	}
+	walk(c)
+	return mirrors
+}
+
+// mirrorsSchedule is when the mirrors comment of a goal is polled again after
+// the first poll, counted from when the goal was posted. Most mirrors come in
+// the first minutes.
+var mirrorsSchedule = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour}
+
+// nextMirrorsPoll returns when to poll a goal posted at postedAt again, having
+// polled it polls times, skipping the slots already past. It is zero once the
+// schedule is over.
+func nextMirrorsPoll(postedAt time.Time, polls int, now time.Time) time.Time {
+	for i := max(polls-1, 0); i < len(mirrorsSchedule); i++ {
+		if next := postedAt.Add(mirrorsSchedule[i]); next.After(now) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	markdownLink = regexp.MustCompile(`\[([^\[\]]*)\]\((https?://[^\s()]+(?:\([^\s()]*\)[^\s()]*)*)\)`)
+	bareLink     = regexp.MustCompile(`https?://[^\s\[\]()<>]+`)
+)
+
+// parseMirrors finds the video links in a comment body. A markdown link is
+// labelled with its text; a bare link with the text before it on the line,
+// e.g. "Alternate angle: https://streamable.com/abc". Links to reddit itself
+// (wiki pages, message links) are not mirrors.
+func parseMirrors(body string) []models.Mirror {
+	var mirrors []models.Mirror
+	add := func(label, link string) {
+		link = strings.TrimRight(link, ".,;:!?*")
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" {
+			return
+		}
+		host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), "m.")
+		if host == "reddit.com" || strings.HasSuffix(host, ".reddit.com") || host == "redd.it" {
+			return
+		}
+		mirrors = append(mirrors, models.Mirror{Host: host, Label: cleanLabel(label), URL: link})
+	}
+
+	body = strings.ReplaceAll(html.UnescapeString(body), `\_`, "_")
+	for _, line := range strings.Split(body, "\n") {
+		prev := 0
+		for _, m := range markdownLink.FindAllStringSubmatchIndex(line, -1) {
+			label := line[m[2]:m[3]]
+			if bareLink.MatchString(label) || strings.TrimSpace(label) == "" {
+				label = line[prev:m[0]]
+			}
+			add(label, line[m[4]:m[5]])
+			prev = m[1]
+		}
+		line, prev = markdownLink.ReplaceAllString(line, "\n"), 0
+		for _, m := range bareLink.FindAllStringIndex(line, -1) {
+			add(line[prev:m[0]], line[m[0]:m[1]])
+			prev = m[1]
+		}
+	}
+	return mirrors
+}
+
+// cleanLabel trims the markdown and punctuation around a label, keeping the
+// last cell of a table row.
+func cleanLabel(label string) string {
+	label = strings.TrimRight(label, " \t|:*-–—")
+	if i := strings.LastIndexAny(label, "|\n"); i >= 0 {
+		label = label[i+1:]
+	}
+	label = strings.Join(strings.Fields(label), " ")
+	label = strings.Trim(label, " *_#>-–—:|")
+	if r := []rune(label); len(r) > maxLabelLen {
+		label = strings.TrimSpace(string(r[:maxLabelLen]))
+	}
+	return label
+}
+
+// fetchMirrors reads the mirrors comment of the post at permalink. It returns
+// the comment's URL and the mirrors found in it, leaving out primary.
+func fetchMirrors(ctx context.Context, c *Client, permalink, primary string) (string, []models.Mirror, error) {
+	var listings []Comments
+	if err := c.Comments(ctx, permalink, &listings); err != nil {
+		return "", nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	if len(listings) < 2 {
+		return "", nil, fmt.Errorf("unexpected JSON structure")
+	}
+
+	comment, ok := findMirrorsComment(listings[1].Data.Children)
+	if !ok {
+		return "", nil, fmt.Errorf("mirrors comment not found")
+	}
+	return "https://www.reddit.com" + comment.Data.Permalink, commentMirrors(comment, primary), nil
+}
@@ -0,0 +1,92 @@
+package reddit
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"blooters/internal/models"
+)
+
+func TestCommentMirrors(t *testing.T) {
+	data, err := os.ReadFile("testdata/comments.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listings []Comments
+	if err := json.Unmarshal(data, &listings); err != nil {
+		t.Fatalf("decoding comments: %v", err)
+	}
+
+	comment, ok := findMirrorsComment(listings[1].Data.Children)
+	if !ok {
+		t.Fatal("mirrors comment not found")
+	}
+	got := commentMirrors(comment, "https://streamable.com/primary")
+	want := []models.Mirror{
+		{Host: "streamff.com", Label: "Broadcast feed", URL: "https://streamff.com/v/a1b2c3"},
+		{Host: "streamin.one", Label: "Mirror", URL: "https://streamin.one/v/xyz789"},
+		{Host: "streamable.com", Label: "Alternate angle", URL: "https://streamable.com/alt_42"},
+		{Host: "streamff.com", Label: "Behind the goal", URL: "https://streamff.com/v/d4e5f6"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commentMirrors() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMirrors(t *testing.T) {
+	tests := []struct {
+		body string
+		want []models.Mirror
+	}{
+		{"", nil},
+		{"no links here", nil},
+		{"[https://streamable.com/a](https://streamable.com/a)",
+			[]models.Mirror{{Host: "streamable.com", URL: "https://streamable.com/a"}}},
+		{"* **Angle from the stands (HD)** - https://www.streamja.com/b.",
+			[]models.Mirror{{Host: "streamja.com", Label: "Angle from the stands (HD)", URL: "https://www.streamja.com/b"}}},
+		{"| Replay | https://streamin.one/c |",
+			[]models.Mirror{{Host: "streamin.one", Label: "Replay", URL: "https://streamin.one/c"}}},
+		{"[one](https://a.com/1) [two](https://b.com/2?x=1&amp;y=2)", []models.Mirror{
+			{Host: "a.com", Label: "one", URL: "https://a.com/1"},
+			{Host: "b.com", Label: "two", URL: "https://b.com/2?x=1&y=2"},
+		}},
+		{"see https://old.reddit.com/r/soccer/wiki and https://redd.it/abc", nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseMirrors(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMirrors(%q) = %+v, want %+v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestNextMirrorsPoll(t *testing.T) {
+	posted := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		polls int
+		now   time.Duration // since posted
+		want  time.Duration // since posted, 0 when done
+	}{
+		{1, 10 * time.Second, time.Minute},
+		{2, time.Minute, 5 * time.Minute},
+		{3, 5 * time.Minute, 15 * time.Minute},
+		{4, 15 * time.Minute, time.Hour},
+		{5, time.Hour, 0},
+		{1, 20 * time.Minute, time.Hour}, // first polled late
+		{1, 2 * time.Hour, 0},
+	}
+
+	for _, tt := range tests {
+		got := nextMirrorsPoll(posted, tt.polls, posted.Add(tt.now))
+		var want time.Time
+		if tt.want != 0 {
+			want = posted.Add(tt.want)
+		}
+		if !got.Equal(want) {
+			t.Errorf("nextMirrorsPoll(%d polls, at +%v) = %v, want %v", tt.polls, tt.now, got, want)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package reddit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"blooters/internal/models"
+	"blooters/internal/names"
+)
+
+// Reasons a title is rejected, see ParseError.
+const (
+	ReasonEmpty     = "empty"      // nothing to parse
+	ReasonNotAGoal  = "not_a_goal" // a save, miss, red card or match thread
+	ReasonHighlight = "highlight"  // "Great goal by ..." without a live score
+	ReasonShootout  = "shootout"   // a penalty shoot-out kick, not a match goal
+	ReasonNoScore   = "no_score"   // no "1-0" style score
+	ReasonNoTeams   = "no_teams"   // a score without a team on both sides
+)
+
+// ParseError explains why a post title could not be read as a goal.
+type ParseError struct {
+	Reason string
+	Title  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("could not parse goal title (%s): %q", e.Reason, e.Title)
+}
+
+// Title is a goal post title broken into its parts.
+type Title struct {
+	HomeTeam    string
+	AwayTeam    string
+	HomeScore   int
+	AwayScore   int
+	Side        string // from the [n] marker, models.SideUnknown without one
+	Scorer      string
+	Minute      string // as typed, e.g. "90+3"
+	MinuteBase  int
+	MinuteAdded int
+	Type        string // a models.GoalType* constant
+	Disallowed  bool
+	Aggregate   string // aggregate score of a two-legged tie, e.g. "4-3"
+	// Confidence is 1 for a title in the canonical
+	// "Home [1]-0 Away - Scorer 12'" shape and drops for every part that had
+	// to be guessed or is missing.
+	Confidence float64
+}
+
+var (
+	threadPattern    = regexp.MustCompile(`(?i)\b(thread|post[- ]match|pre[- ]match)\b`)
+	notAGoalPattern  = regexp.MustCompile(`(?i)\b(saves?|saved|save by|red card|sent off|miss(es|ed)?|penalty miss)\b`)
+	highlightPattern = regexp.MustCompile(`(?i)^\s*(great|amazing|incredible|brilliant|stunning|fantastic|beautiful)?\s*(goal|strike|finish|solo goal|free kick|volley|header)\s+(by|from)\b`)
+	shootoutPattern  = regexp.MustCompile(`(?i)\bshoot[ -]?outs?\b|\b(on )?pen(alties|s)\b|\bpso\b`)
+	aggregatePattern = regexp.MustCompile(`(?i)\bagg(regate)?\b`)
+	// Words before the scorer's name, e.g. "Great goal by Saka"
+	scorerLead = regexp.MustCompile(`(?i)^((great|amazing|incredible|brilliant|stunning|fantastic|wonder|solo|long range|own|free kick|penalty)\s+)*(goal|strike|finish|header|volley|effort)?\s*(by|from)\s+`)
+	// Words after the scorer's name describing the goal, e.g. "Saka great solo
+	// goal"; "own" so that "Dias own goal" doesn't leave it behind
+	scorerTail = regexp.MustCompile(`(?i)(\s+(great|amazing|incredible|brilliant|stunning|fantastic|wonder|solo|long|range|goal|strike|finish|header|volley|screamer|stunner|chip|worldie|bicycle|overhead|rocket|tap-in|tap|in|from|distance|own))+$`)
+)
+
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota
+	tokNumber           // a score or a minute
+	tokDash             // -, – or —, but not the hyphen inside a word
+	tokOpen             // ( or [ opening a note
+	tokClose
+	tokPunct // : , | ;
+)
+
+type token struct {
+	kind       tokenKind
+	start, end int // byte offsets in the title
+	num, added int // 90 and 3 for "90+3'"
+	marked     bool
+	prime      bool // followed by ' or ′, so a minute
+}
+
+func isDash(r rune) bool {
+	return r == '-' || r == '–' || r == '—' || r == '―' || r == '−'
+}
+
+func isPrime(r rune) bool {
+	return r == '\'' || r == '′' || r == '’' || r == '´'
+}
+
+// tokenize splits a title into tokens. "[1]" is a single marked number; a
+// hyphen between letters ("Saint-Germain") stays inside the word.
+func tokenize(s string) []token {
+	var toks []token
+	at := func(i int) rune {
+		if i >= len(s) {
+			return 0
+		}
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		return r
+	}
+	digitsEnd := func(i int) int {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r == '[' && digitsEnd(i+1) > i+1 && at(digitsEnd(i+1)) == ']':
+			end := digitsEnd(i + 1)
+			n, _ := strconv.Atoi(s[i+1 : end])
+			toks = append(toks, token{kind: tokNumber, start: i, end: end + 1, num: n, marked: true})
+			i = end + 1
+
+		case r == '(' || r == '[' || r == '{':
+			toks = append(toks, token{kind: tokOpen, start: i, end: i + size})
+			i += size
+
+		case r == ')' || r == ']' || r == '}':
+			toks = append(toks, token{kind: tokClose, start: i, end: i + size})
+			i += size
+
+		case isDash(r):
+			toks = append(toks, token{kind: tokDash, start: i, end: i + size})
+			i += size
+
+		case r == ':' || r == ',' || r == '|' || r == ';':
+			toks = append(toks, token{kind: tokPunct, start: i, end: i + size})
+			i += size
+
+		case r >= '0' && r <= '9' && !unicode.IsLetter(at(digitsEnd(i))) && at(digitsEnd(i)) != '.':
+			// 12, 12', 90+3, 90+3', 90'+3'
+			end := digitsEnd(i)
+			t := token{kind: tokNumber, start: i}
+			t.num, _ = strconv.Atoi(s[i:end])
+			if p := at(end); isPrime(p) {
+				t.prime = true
+				end += utf8.RuneLen(p)
+			}
+			if at(end) == '+' && digitsEnd(end+1) > end+1 {
+				addEnd := digitsEnd(end + 1)
+				t.added, _ = strconv.Atoi(s[end+1 : addEnd])
+				end = addEnd
+				if p := at(end); isPrime(p) {
+					t.prime = true
+					end += utf8.RuneLen(p)
+				}
+			}
+			t.end = end
+			toks = append(toks, t)
+			i = end
+
+		default:
+			start := i
+			for i < len(s) {
+				r, size := utf8.DecodeRuneInString(s[i:])
+				if unicode.IsSpace(r) || strings.ContainsRune("()[]{}:,|;", r) {
+					break
+				}
+				if isDash(r) && !(r == '-' && i > start && unicode.IsLetter(at(i+size)) &&
+					unicode.IsLetter(lastRune(s[start:i]))) {
+					break
+				}
+				i += size
+			}
+			toks = append(toks, token{kind: tokWord, start: start, end: i})
+		}
+	}
+	return toks
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// span returns the title text covered by toks[from:to].
+func span(s string, toks []token, from, to int) string {
+	if from >= to {
+		return ""
+	}
+	return strings.TrimSpace(s[toks[from].start:toks[to-1].end])
+}
+
+// isScoreNumber reports whether t can be one side of a match score.
+func isScoreNumber(t token) bool {
+	return t.kind == tokNumber && !t.prime && t.added == 0
+}
+
+// groupEnd returns the index just past the group opened at toks[i].
+func groupEnd(toks []token, i int) int {
+	depth := 0
+	for j := i; j < len(toks); j++ {
+		switch toks[j].kind {
+		case tokOpen:
+			depth++
+		case tokClose:
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(toks)
+}
+
+// ParseTitle reads a goal post title such as
+//
+//	Arsenal [2]-1 Chelsea - Bukayo Saka 45+2'
+//
+// The [n] marker tells which side scored. En and em dashes, notes in
+// brackets ("(pen)", "[VAR]", "(4-3 on agg.)"), and stoppage time are
+// understood. Saves, misses, shoot-out kicks and "Great goal by" posts are
+// rejected with a *ParseError naming the reason.
+func ParseTitle(title string) (Title, error) {
+	s := strings.TrimSpace(title)
+	if s == "" {
+		return Title{}, &ParseError{Reason: ReasonEmpty, Title: title}
+	}
+	toks := tokenize(s)
+
+	// Find the match score, outside any brackets: n-n with optional markers
+	score := -1
+	for i, depth := 0, 0; i+2 < len(toks); i++ {
+		switch toks[i].kind {
+		case tokOpen:
+			depth++
+		case tokClose:
+			depth = max(depth-1, 0)
+		}
+		if depth == 0 && isScoreNumber(toks[i]) && toks[i+1].kind == tokDash && isScoreNumber(toks[i+2]) {
+			score = i
+			break
+		}
+	}
+
+	if shootoutPattern.MatchString(s) {
+		return Title{}, &ParseError{Reason: ReasonShootout, Title: title}
+	}
+	if threadPattern.MatchString(s) {
+		return Title{}, &ParseError{Reason: ReasonNotAGoal, Title: title}
+	}
+	// A [n] marker says a goal went in, whatever the notes say about a save
+	// or a miss on the way, e.g. "(great save then rebound)"
+	marked := score >= 0 && (toks[score].marked || toks[score+2].marked)
+	if !marked && notAGoalPattern.MatchString(s) {
+		return Title{}, &ParseError{Reason: ReasonNotAGoal, Title: title}
+	}
+	if score < 0 {
+		if highlightPattern.MatchString(s) {
+			return Title{}, &ParseError{Reason: ReasonHighlight, Title: title}
+		}
+		return Title{}, &ParseError{Reason: ReasonNoScore, Title: title}
+	}
+
+	t := Title{
+		HomeScore:  toks[score].num,
+		AwayScore:  toks[score+2].num,
+		Confidence: 1,
+	}
+	if highlightPattern.MatchString(span(s, toks, 0, score)) {
+		return Title{}, &ParseError{Reason: ReasonHighlight, Title: title}
+	}
+	// A prefix such as "Premier League: " isn't part of the home team
+	homeStart := 0
+	for j := score - 1; j >= 0; j-- {
+		if toks[j].kind == tokPunct {
+			homeStart = j + 1
+			t.Confidence -= 0.1
+			break
+		}
+	}
+	t.HomeTeam = span(s, toks, homeStart, score)
+
+	// The away team runs until the scorer separator or a note
+	i := score + 3
+	awayStart := i
+	for i < len(toks) && toks[i].kind != tokDash && toks[i].kind != tokOpen && toks[i].kind != tokPunct {
+		i++
+	}
+	awayEnd := i
+
+	// Everything after is notes and the scorer part
+	var notes []string
+	scorerStart := -1
+	for i < len(toks) {
+		switch toks[i].kind {
+		case tokOpen:
+			end := groupEnd(toks, i)
+			notes = append(notes, span(s, toks, i, end))
+			i = end
+		case tokDash, tokPunct:
+			if scorerStart < 0 {
+				scorerStart = i + 1
+			}
+			i++
+		default:
+			if scorerStart < 0 {
+				scorerStart = i
+			}
+			i++
+		}
+	}
+
+	// Without a separator the away team may swallow the scorer; cut it at
+	// a trailing minute at least
+	if scorerStart < 0 && awayEnd > awayStart {
+		last := toks[awayEnd-1]
+		if last.kind == tokNumber && (last.prime || last.added > 0) {
+			t.Minute = minuteText(last)
+			t.MinuteBase, t.MinuteAdded = last.num, last.added
+			awayEnd--
+		}
+		t.Confidence -= 0.2
+	}
+	t.AwayTeam = span(s, toks, awayStart, awayEnd)
+	if t.HomeTeam == "" || t.AwayTeam == "" {
+		return Title{}, &ParseError{Reason: ReasonNoTeams, Title: title}
+	}
+
+	// Scorer part: name, minute and notes
+	var words []string
+	if scorerStart >= 0 {
+		minute := -1
+		for j := scorerStart; j < len(toks); j++ {
+			switch toks[j].kind {
+			case tokOpen:
+				end := groupEnd(toks, j)
+				notes = append(notes, span(s, toks, j, end))
+				j = end - 1
+			case tokNumber:
+				if minute < 0 || toks[j].prime || toks[j].added > 0 {
+					minute = j
+				}
+			case tokWord:
+				if minute < 0 {
+					words = append(words, s[toks[j].start:toks[j].end])
+				} else {
+					notes = append(notes, s[toks[j].start:toks[j].end])
+				}
+			case tokDash, tokPunct:
+				// second separator, e.g. "Saka - 45'"
+			}
+		}
+		if minute >= 0 {
+			m := toks[minute]
+			t.Minute = minuteText(m)
+			t.MinuteBase, t.MinuteAdded = m.num, m.added
+			if !m.prime && m.added == 0 {
+				t.Confidence -= 0.05
+			}
+		}
+	}
+	scorerText := strings.Join(words, " ")
+	notesText := strings.Join(notes, " ")
+
+	t.Type = goalType(scorerText + " " + notesText)
+	t.Disallowed = disallowedPattern.MatchString(s)
+	for _, n := range notes {
+		if aggregatePattern.MatchString(n) {
+			if agg := aggregateScore.FindStringSubmatch(n); agg != nil {
+				t.Aggregate = agg[1] + "-" + agg[2]
+			}
+		}
+	}
+
+	scorerText = scorerLead.ReplaceAllString(scorerText, "")
+	if trimmed := scorerTail.ReplaceAllString(scorerText, ""); trimmed != "" {
+		scorerText = trimmed
+	}
+	t.Scorer = names.PlayerName(scorerText)
+
+	// Which side scored
+	home, away := toks[score].marked, toks[score+2].marked
+	switch {
+	case home && !away && t.HomeScore > 0:
+		t.Side = models.SideHome
+	case away && !home && t.AwayScore > 0:
+		t.Side = models.SideAway
+	default:
+		// no marker, both, or a marker on a side without goals
+		t.Side = models.SideUnknown
+		t.Confidence -= 0.3
+	}
+
+	if t.Scorer == "" {
+		t.Confidence -= 0.2
+	}
+	if t.Minute == "" {
+		t.Confidence -= 0.2
+	}
+	for _, team := range []string{t.HomeTeam, t.AwayTeam} {
+		if utf8.RuneCountInString(team) > 40 || strings.IndexFunc(team, unicode.IsLetter) < 0 {
+			t.Confidence -= 0.2
+		}
+	}
+	t.Confidence = max(0, min(1, float64(int(t.Confidence*100+0.5))/100))
+	return t, nil
+}
+
+var aggregateScore = regexp.MustCompile(`(\d+)\s*\]?\s*[-–—]\s*\[?\s*(\d+)`)
+
+func minuteText(t token) string {
+	if t.added > 0 {
+		return fmt.Sprintf("%d+%d", t.num, t.added)
+	}
+	return strconv.Itoa(t.num)
+}
+
+// Goal fills in a goal from the parsed title.
+func (t Title) Goal(url, permalink, title string) models.Goal {
+	return models.Goal{
+		Url:         url,
+		RedditURL:   "https://www.reddit.com" + permalink,
+		Description: title,
+		HomeTeam:    t.HomeTeam,
+		AwayTeam:    t.AwayTeam,
+		HomeScore:   t.HomeScore,
+		AwayScore:   t.AwayScore,
+		Goalscorer:  t.Scorer,
+		Minute:      t.Minute,
+		MinuteBase:  t.MinuteBase,
+		MinuteAdded: t.MinuteAdded,
+		Type:        t.Type,
+		Disallowed:  t.Disallowed,
+		Away:        t.Side == models.SideAway,
+		Side:        t.Side,
+	}
+}
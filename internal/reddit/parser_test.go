@@ -0,0 +1,159 @@
+package reddit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blooters/internal/models"
+)
+
+// readCorpus returns the tab-separated records of a testdata file, skipping
+// blank lines and # comments. Every record must have n fields.
+func readCorpus(t *testing.T, name string, n int) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records [][]string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != n {
+			t.Fatalf("%s:%d: got %d fields, want %d", name, line, len(fields), n)
+		}
+		records = append(records, fields)
+	}
+	return records
+}
+
+// TestParseTitleCorpus parses the goal titles in testdata/titles.tsv and
+// checks every field, confidence included, against the expectation written
+// next to it.
+func TestParseTitleCorpus(t *testing.T) {
+	for _, r := range readCorpus(t, "titles.tsv", 10) {
+		title := r[0]
+		got, err := ParseTitle(title)
+		if err != nil {
+			t.Errorf("ParseTitle(%q): %v", title, err)
+			continue
+		}
+
+		var disallowed bool
+		var aggregate string
+		if r[8] != "-" {
+			for _, flag := range strings.Split(r[8], ",") {
+				switch {
+				case flag == "disallowed":
+					disallowed = true
+				case strings.HasPrefix(flag, "agg="):
+					aggregate = strings.TrimPrefix(flag, "agg=")
+				default:
+					t.Fatalf("%q: unknown flag %q", title, flag)
+				}
+			}
+		}
+
+		checks := []struct{ field, got, want string }{
+			{"home", got.HomeTeam, r[1]},
+			{"away", got.AwayTeam, r[2]},
+			{"score", fmt.Sprintf("%d-%d", got.HomeScore, got.AwayScore), r[3]},
+			{"side", got.Side, r[4]},
+			{"scorer", got.Scorer, r[5]},
+			{"minute", got.Minute, r[6]},
+			{"type", got.Type, r[7]},
+			{"disallowed", fmt.Sprint(got.Disallowed), fmt.Sprint(disallowed)},
+			{"aggregate", got.Aggregate, aggregate},
+			{"confidence", strconv.FormatFloat(got.Confidence, 'f', -1, 64), r[9]},
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("%q: %s = %q, want %q", title, c.field, c.got, c.want)
+			}
+		}
+	}
+}
+
+// TestParseTitleRejected checks that the posts in testdata/rejected.tsv,
+// which are not goals, stay rejected and for the right reason.
+func TestParseTitleRejected(t *testing.T) {
+	for _, r := range readCorpus(t, "rejected.tsv", 2) {
+		reason, title := r[0], r[1]
+		got, err := ParseTitle(title)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("ParseTitle(%q) = %+v, %v; want rejection %q", title, got, err, reason)
+			continue
+		}
+		if perr.Reason != reason {
+			t.Errorf("ParseTitle(%q) rejected as %q, want %q", title, perr.Reason, reason)
+		}
+	}
+}
+
+func TestParseTitleSide(t *testing.T) {
+	tests := []struct {
+		title    string
+		wantSide string
+		wantAway bool
+	}{
+		// The old "AwayScore > HomeScore" rule got this one wrong
+		{"Arsenal [2]-3 Chelsea - Declan Rice 80'", models.SideHome, false},
+		{"Arsenal 2-[3] Chelsea - Cole Palmer 80'", models.SideAway, true},
+		{"Arsenal 2-3 Chelsea - Cole Palmer 80'", models.SideUnknown, false},
+		{"Arsenal [0]-1 Chelsea - Cole Palmer 80'", models.SideUnknown, false},
+	}
+
+	for _, tt := range tests {
+		parsed, err := ParseTitle(tt.title)
+		if err != nil {
+			t.Errorf("ParseTitle(%q) error = %v", tt.title, err)
+			continue
+		}
+		if parsed.Side != tt.wantSide {
+			t.Errorf("ParseTitle(%q) Side = %q, want %q", tt.title, parsed.Side, tt.wantSide)
+		}
+		if parsed.Side == models.SideUnknown && parsed.Confidence >= 1 {
+			t.Errorf("ParseTitle(%q) Confidence = %v, want it lowered for an unknown side", tt.title, parsed.Confidence)
+		}
+		goal, _ := ParseGoalFromTitle(tt.title, "https://streamable.com/example", "/r/soccer/comments/example")
+		if goal.Away != tt.wantAway {
+			t.Errorf("ParseGoalFromTitle(%q) Away = %v, want %v", tt.title, goal.Away, tt.wantAway)
+		}
+	}
+}
+
+func TestParseTitleNotAGoal(t *testing.T) {
+	tests := []struct {
+		title      string
+		wantReason string // "" when the title is a goal
+	}{
+		{"Arsenal [1]-0 Chelsea - Saka 12' (great save then rebound)", ""},
+		{"Liverpool [1]-0 Barcelona - Divock Origi 7' (rebound after ter Stegen save)", ""},
+		{"Arsenal 2-[1] Chelsea - Cole Palmer 90' (scores after Raya missed the cross)", ""},
+		{"Arsenal 1-0 Chelsea - Raya save vs Palmer 90'", ReasonNotAGoal},
+		{"Arsenal 1-0 Chelsea - Palmer penalty miss 60'", ReasonNotAGoal},
+		{"Post-Match Thread: Arsenal [5]-0 Chelsea", ReasonNotAGoal},
+	}
+	for _, tt := range tests {
+		_, err := ParseTitle(tt.title)
+		var perr *ParseError
+		switch {
+		case tt.wantReason == "" && err != nil:
+			t.Errorf("ParseTitle(%q) error = %v, want a goal", tt.title, err)
+		case tt.wantReason != "" && (!errors.As(err, &perr) || perr.Reason != tt.wantReason):
+			t.Errorf("ParseTitle(%q) error = %v, want reason %s", tt.title, err, tt.wantReason)
+		}
+	}
+}
@@ -1,27 +1,28 @@
 package reddit
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
 	"blooters/internal/db"
 	"blooters/internal/models"
 )
 
-const RedditAPIURL = "https://www.reddit.com/r/soccer/new.json?limit=50"
+// maxBackfillPages bounds how far back FetchGoals pages when looking for the
+// cursor, i.e. at most 1000 posts after a long outage.
+const maxBackfillPages = 10
 
 type RedditResponse struct {
 	Kind string `json:"kind"`
 	Data struct {
+		After    string `json:"after"`
 		Children []struct {
 			Kind string `json:"kind"`
 			Data struct {
+				Name      string  `json:"name"`
 				Title     string  `json:"title"`
 				URL       string  `json:"url"`
 				Permalink string  `json:"permalink"`
@@ -55,276 +56,133 @@ type PostAndComments struct {
 	Comments Comments    `json:"1"`
 }
 
-// FetchGoals fetches goals from Reddit API and parses them
-func FetchGoals() ([]models.Goal, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", RedditAPIURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "blooters/1.0 (goal scraper)")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch from Reddit: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("reddit API returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var redditResp RedditResponse
-	if err := json.Unmarshal(body, &redditResp); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
-	}
-
-	var goals []models.Goal
-	for _, child := range redditResp.Data.Children {
-		if child.Kind != "t3" || child.Data.FlairText != "Media" {
-			continue
-		}
-
-		// Parse the title to extract goal information
-		goal, err := ParseGoalFromTitle(child.Data.Title, child.Data.URL, child.Data.Permalink)
+// FetchGoals fetches goals from a subreddit's newest posts and parses them.
+// It pages backwards through the listing until it reaches the post with
+// fullname until (exclusive), so nothing posted since the previous fetch is
+// missed. With an empty until only the first page is read. newest is the
+// fullname of the most recent post seen, to be used as the next until.
+// Media posts whose title doesn't parse are returned in rejected.
+func FetchGoals(ctx context.Context, c *Client, subreddit, until string) (goals []models.Goal, rejected []models.ParseFailure, newest string, err error) {
+	after := ""
+	for page := 0; page < maxBackfillPages; page++ {
+		listing, err := c.Listing(ctx, subreddit, after)
 		if err != nil {
-			// Skip posts that don't match goal format
-			continue
-		}
-
-		goals = append(goals, goal)
-	}
-
-	return goals, nil
-}
-
-func ParseGoalFromTitle(title, url, permalink string) (models.Goal, error) {
-	goal := models.Goal{
-		Url:         url,
-		RedditURL:   "https://www.reddit.com" + permalink,
-		Description: title,
-	}
-
-	normalized := strings.NewReplacer("–", "-", "—", "-", "  ", " ").Replace(title)
-
-	//I hate regexes but here we go:
-	// 1: home team
-	// 2: home score
-	// 3: away score
-	// 4: away team
-	scorePattern := regexp.MustCompile(`(?m)^(.+?)\s+\[?(\d+)\]?\s*-\s*\[?(\d+)\]?\s+(.+?)\s*-\s*(.+)$`)
-	matches := scorePattern.FindStringSubmatch(normalized)
-	if len(matches) != 6 {
-		// I really hate regexes. Fallback: try without the final dash (in case title has no dash before scorer)
-		scorePatternAlt := regexp.MustCompile(`(?m)^(.+?)\s+\[?(\d+)\]?\s*-\s*\[?(\d+)\]?\s+(.+)$`)
-		matches = scorePatternAlt.FindStringSubmatch(normalized)
-		if len(matches) != 5 {
-			return goal, fmt.Errorf("could not parse teams and score")
+			return nil, nil, "", err
 		}
-		// In this fallback, scorer part is empty; we will parse manually later
-		matches = append(matches, "")
-	}
-
-	goal.HomeTeam = strings.TrimSpace(matches[1])
-	goal.AwayTeam = strings.TrimSpace(matches[4])
 
-	homeScore, _ := strconv.Atoi(matches[2])
-	awayScore, _ := strconv.Atoi(matches[3])
-	goal.HomeScore = homeScore
-	goal.AwayScore = awayScore
-
-	scorerPart := strings.TrimSpace(matches[5])
-	if scorerPart == "" {
-		// TRY to extract scorer info from remaining string
-		idx := strings.LastIndex(normalized, "-")
-		if idx >= 0 && idx+1 < len(normalized) {
-			scorerPart = strings.TrimSpace(normalized[idx+1:])
+		reachedCursor := false
+		for _, child := range listing.Data.Children {
+			if newest == "" {
+				newest = child.Data.Name
+			}
+			if until != "" && child.Data.Name == until {
+				reachedCursor = true
+				break
+			}
+
+			if child.Kind != "t3" || child.Data.FlairText != "Media" {
+				continue
+			}
+
+			// Parse the title to extract goal information
+			postedAt := time.Unix(int64(child.Data.Created), 0).UTC()
+			goal, err := ParseGoalFromTitle(child.Data.Title, child.Data.URL, child.Data.Permalink)
+			if err != nil {
+				// Keep posts that don't match goal format for review
+				rejected = append(rejected, models.ParseFailure{
+					Title:     child.Data.Title,
+					URL:       child.Data.URL,
+					Permalink: child.Data.Permalink,
+					Reason:    failureReason(err),
+					PostedAt:  postedAt,
+				})
+				continue
+			}
+			goal.PostedAt = postedAt
+
+			goals = append(goals, goal)
 		}
-	}
 
-	// Extract minute and scorer
-	minutePattern := regexp.MustCompile(`(.+?)\s+(\d+(?:\+\d+)?)[′']?$`)
-	mm := minutePattern.FindStringSubmatch(scorerPart)
-	if len(mm) == 3 {
-		rawScorer := strings.TrimSpace(mm[1])
-		min := strings.TrimSpace(mm[2])
-
-		// Remove penalty/own goal markers
-		lower := strings.ToLower(rawScorer)
-		removeMods := []string{"(pen)", "pen", "penalty", "(og)", "own goal", "og"}
-		for _, m := range removeMods {
-			lower = strings.TrimSpace(strings.TrimSuffix(lower, " "+m))
-			lower = strings.TrimSpace(strings.TrimSuffix(lower, " ("+m+")"))
-		}
-
-		goal.Goalscorer = strings.Title(lower)
-		goal.Minute = min
-	} else {
-		// fallback: last field is minute, everything before is the scorer name
-		fields := strings.Fields(scorerPart)
-		if len(fields) >= 2 {
-			// Last field should be the minute (possibly with ' or ′)
-			minute := fields[len(fields)-1]
-			minute = strings.Trim(minute, "′'")
-			goal.Minute = minute
-
-			// Everything else is the goalscorer name
-			goal.Goalscorer = strings.Join(fields[:len(fields)-1], " ")
-		} else if len(fields) == 1 {
-			// Only one field, assume it's the scorer
-			goal.Goalscorer = fields[0]
+		after = listing.Data.After
+		if until == "" || reachedCursor || after == "" {
+			return goals, rejected, newest, nil
 		}
 	}
 
-	// Home or away?
-	goal.Away = goal.AwayScore > homeScore
-
-	return goal, nil
+	fmt.Printf("Warning: cursor %s not found within %d pages of r/%s, some goals may be missing\n", until, maxBackfillPages, subreddit)
+	return goals, rejected, newest, nil
 }
 
-func getMirrorsLink(postURL string) (string, error) {
-	if !strings.HasSuffix(postURL, "/") {
-		postURL += "/"
-	}
-	commentsURL := postURL + ".json"
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", commentsURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "blooters/1.0 (goal scraper)")
+// Markers of how a goal was scored, looked for after the score. Own goal wins
+// over penalty wins over free kick when a title has several. A mention of VAR
+// alone does not make a goal disallowed ("goal stands after VAR check"); it
+// has to come with a verdict.
+var (
+	ownGoalPattern    = regexp.MustCompile(`(?i)\(\s*o\.?g\.?\s*\)|\bown[ -]goal\b|\bog\b|\bo\.g\b`)
+	penaltyPattern    = regexp.MustCompile(`(?i)\(\s*p(en(alty)?)?\.?\s*\)|\bpen(alty)?\b`)
+	freeKickPattern   = regexp.MustCompile(`(?i)\(\s*fk\s*\)|\bfree[ -]kick\b`)
+	disallowedPattern = regexp.MustCompile(`(?i)\b(disallowed|ruled out|chalked off)\b|\bvar\b.*\b(no goal|overturned)\b|\b(no goal|overturned)\b.*\bvar\b`)
+)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch comments: %w", err)
-	}
-	defer resp.Body.Close()
+// goalType classifies a goal from the part of the title after the score.
+func goalType(s string) string {
+	switch {
+	case ownGoalPattern.MatchString(s):
+		return models.GoalTypeOwnGoal
+	case penaltyPattern.MatchString(s):
+		return models.GoalTypePenalty
+	case freeKickPattern.MatchString(s):
+		return models.GoalTypeFreeKick
+	}
+	return models.GoalTypeOpenPlay
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("comments API returned status %d", resp.StatusCode)
+// failureReason is the ParseError reason of err.
+func failureReason(err error) string {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return pe.Reason
 	}
+	return "unknown"
+}
 
-	body, err := io.ReadAll(resp.Body)
+// ParseGoalFromTitle parses a goal post, see ParseTitle. Errors are *ParseError.
+func ParseGoalFromTitle(title, url, permalink string) (models.Goal, error) {
+	t, err := ParseTitle(title)
 	if err != nil {
-		return "", fmt.Errorf("failed to read comments body: %w", err)
+		return models.Goal{Url: url, RedditURL: "https://www.reddit.com" + permalink, Description: title}, err
 	}
-
-	var data []interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		return "", fmt.Errorf("failed to parse comments JSON: %w", err)
-	}
-
-	if len(data) < 2 {
-		return "", fmt.Errorf("unexpected JSON structure")
-	}
-
-	commentsData, ok := data[1].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("comments data not found")
-	}
-
-	children, ok := commentsData["data"].(map[string]interface{})["children"].([]interface{})
-	if !ok {
-		return "", fmt.Errorf("comments children not found")
-	}
-
-	for _, child := range children {
-		comment, ok := child.(map[string]interface{})
-		if !ok || comment["kind"].(string) != "t1" {
-			continue
-		}
-
-		commentData, ok := comment["data"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		author, ok := commentData["author"].(string)
-		if !ok || author != "AutoModerator" {
-			continue
-		}
-
-		body, ok := commentData["body"].(string)
-		if !ok || !strings.Contains(body, "Mirrors / Alternative Angles") {
-			continue
-		}
-
-		permalink, ok := commentData["permalink"].(string)
-		if !ok {
-			continue
-		}
-
-		return "https://www.reddit.com" + permalink, nil
-	}
-
-	return "", fmt.Errorf("mirrors comment not found")
+	return t.Goal(url, permalink, title), nil
 }
 
-func PopulateMirrors() error {
-	if db.DB == nil {
-		return fmt.Errorf("database not initialized")
-	}
-
-	// Get up to 5 goals without mirrors
-	rows, err := db.DB.Query("SELECT id, reddit_url FROM goals WHERE mirrors = '' AND reddit_url != '' LIMIT 5")
+// PopulateMirrors polls the mirrors comment of the goals that are due, see
+// mirrorsSchedule, and stores the mirrors posted under it since the last
+// poll. Requests go through c and are paced by its shared rate-limit budget.
+func PopulateMirrors(ctx context.Context, c *Client, store *db.Store) error {
+	// Get up to 5 goals due for a poll
+	due, err := store.GoalsDueForMirrors(ctx, 5)
 	if err != nil {
-		return fmt.Errorf("failed to query goals without mirrors: %w", err)
-	}
-	defer rows.Close()
-
-	var goalsToUpdate []struct {
-		ID        int
-		RedditURL string
-	}
-
-	for rows.Next() {
-		var id int
-		var redditURL string
-		if err := rows.Scan(&id, &redditURL); err != nil {
-			return fmt.Errorf("failed to scan goal: %w", err)
-		}
-		goalsToUpdate = append(goalsToUpdate, struct {
-			ID        int
-			RedditURL string
-		}{ID: id, RedditURL: redditURL})
-	}
-
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("rows error: %w", err)
+		return err
 	}
 
 	// For each, fetch mirrors
-	for _, g := range goalsToUpdate {
-		mirrorsLink, err := getMirrorsLink(g.RedditURL)
+	for _, p := range due {
+		mirrorsURL, mirrors, err := fetchMirrors(ctx, c, p.RedditURL, p.Url)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
-			fmt.Printf("Warning: failed to get mirrors for goal %d: %v\n", g.ID, err)
-			continue
+			// Still counts as a poll, so a deleted post isn't retried forever
+			fmt.Printf("Warning: failed to get mirrors for goal %d: %v\n", p.GoalID, err)
 		}
 
 		// Update DB
-		_, err = db.DB.Exec("UPDATE goals SET mirrors = $1 WHERE id = $2", mirrorsLink, g.ID)
-		if err != nil {
-			fmt.Printf("Warning: failed to update mirrors for goal %d: %v\n", g.ID, err)
-		} else {
-			fmt.Printf("Updated mirrors for goal %d\n", g.ID)
+		next := nextMirrorsPoll(p.PostedAt, p.Polls+1, time.Now())
+		if err := store.RecordMirrorsPoll(ctx, p.GoalID, mirrorsURL, mirrors, next); err != nil {
+			fmt.Printf("Warning: failed to update mirrors for goal %d: %v\n", p.GoalID, err)
+		} else if mirrorsURL != "" {
+			fmt.Printf("Updated mirrors for goal %d (%d links)\n", p.GoalID, len(mirrors))
 		}
-
-		// Sleep to avoid rate limiting
-		time.Sleep(1 * time.Second)
 	}
 
 	return nil
@@ -3,6 +3,8 @@ package reddit
 import (
 	"fmt"
 	"testing"
+
+	"blooters/internal/models"
 )
 
 func TestParseGoalFromTitle(t *testing.T) {
@@ -51,6 +53,33 @@ func TestParseGoalFromTitle(t *testing.T) {
 			wantAs:   2,
 			wantGoal: "Tyrese Hall",
 		},
+		{
+			title:    "Liverpool [1]-0 Everton - Virgil van Dijk 23'",
+			url:      "https://streamable.com/example",
+			wantHome: "Liverpool",
+			wantAway: "Everton",
+			wantHs:   1,
+			wantAs:   0,
+			wantGoal: "Virgil van Dijk",
+		},
+		{
+			title:    "Chelsea 0-[1] Manchester United - McTominay (pen) 90+2'",
+			url:      "https://streamable.com/example",
+			wantHome: "Chelsea",
+			wantAway: "Manchester United",
+			wantHs:   0,
+			wantAs:   1,
+			wantGoal: "McTominay",
+		},
+		{
+			title:    "Chelsea [2]-1 Arsenal - N'Golo Kanté 61'",
+			url:      "https://streamable.com/example",
+			wantHome: "Chelsea",
+			wantAway: "Arsenal",
+			wantHs:   2,
+			wantAs:   1,
+			wantGoal: "N'Golo Kanté",
+		},
 	}
 
 	for _, tt := range tests {
@@ -78,6 +107,47 @@ func TestParseGoalFromTitle(t *testing.T) {
 	}
 }
 
+func TestParseGoalMetadata(t *testing.T) {
+	tests := []struct {
+		title          string
+		wantType       string
+		wantBase       int
+		wantAdded      int
+		wantDisallowed bool
+		wantScorer     string
+	}{
+		{"Arsenal [1]-0 Chelsea - Bukayo Saka 23'", models.GoalTypeOpenPlay, 23, 0, false, "Bukayo Saka"},
+		{"Arsenal [1]-0 Chelsea - Bukayo Saka (pen) 45+2'", models.GoalTypePenalty, 45, 2, false, "Bukayo Saka"},
+		{"Arsenal [1]-0 Chelsea - Bukayo Saka penalty 90+10'", models.GoalTypePenalty, 90, 10, false, "Bukayo Saka"},
+		{"Arsenal 0-[1] Chelsea - Gabriel OG 12'", models.GoalTypeOwnGoal, 12, 0, false, "Gabriel"},
+		{"Arsenal 0-[1] Chelsea - Gabriel (o.g.) 12'", models.GoalTypeOwnGoal, 12, 0, false, "Gabriel"},
+		{"Arsenal [2]-1 Chelsea - Martin Ødegaard free kick 67'", models.GoalTypeFreeKick, 67, 0, false, "Martin Ødegaard"},
+		{"Arsenal [2]-1 Chelsea - Martin Ødegaard 67' (FK)", models.GoalTypeFreeKick, 67, 0, false, "Martin Ødegaard"},
+		{"Arsenal [2]-1 Chelsea - Kai Havertz 88' (disallowed)", models.GoalTypeOpenPlay, 88, 0, true, "Kai Havertz"},
+		{"Arsenal [2]-1 Chelsea - Kai Havertz 88' [Ruled out by VAR]", models.GoalTypeOpenPlay, 88, 0, true, "Kai Havertz"},
+	}
+
+	for _, tt := range tests {
+		goal, err := ParseGoalFromTitle(tt.title, "https://streamable.com/example", "/r/soccer/comments/example")
+		if err != nil {
+			t.Errorf("ParseGoalFromTitle(%q) error = %v", tt.title, err)
+			continue
+		}
+		if goal.Type != tt.wantType {
+			t.Errorf("ParseGoalFromTitle(%q) Type = %q, want %q", tt.title, goal.Type, tt.wantType)
+		}
+		if goal.MinuteBase != tt.wantBase || goal.MinuteAdded != tt.wantAdded {
+			t.Errorf("ParseGoalFromTitle(%q) minute = %d+%d, want %d+%d", tt.title, goal.MinuteBase, goal.MinuteAdded, tt.wantBase, tt.wantAdded)
+		}
+		if goal.Disallowed != tt.wantDisallowed {
+			t.Errorf("ParseGoalFromTitle(%q) Disallowed = %v, want %v", tt.title, goal.Disallowed, tt.wantDisallowed)
+		}
+		if goal.Goalscorer != tt.wantScorer {
+			t.Errorf("ParseGoalFromTitle(%q) Goalscorer = %q, want %q", tt.title, goal.Goalscorer, tt.wantScorer)
+		}
+	}
+}
+
 func ExampleParseGoalFromTitle() {
 	title := "Arsenal [1]-0 Leeds United - Thierry Henry 78'"
 	goal, _ := ParseGoalFromTitle(title, "https://www.youtube.com/watch?v=_bNBN9XlTK0", "/r/soccer/comments/example")
@@ -0,0 +1,90 @@
+package reddit
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"blooters/internal/ingest"
+	"blooters/internal/models"
+)
+
+func init() {
+	ingest.Register("reddit", func(subreddit string, cursors ingest.CursorStore) (ingest.GoalSource, error) {
+		if subreddit == "" {
+			subreddit = "soccer"
+		}
+		return NewSource(DefaultClient(), cursors, subreddit), nil
+	})
+}
+
+// Source reads goal posts flaired "Media" from a subreddit. It keeps the
+// fullname of the newest post it has handed out as a cursor, persisted in
+// cursors once the goals are stored, so each fetch only returns new posts and
+// a restart backfills whatever was posted while the process was down.
+type Source struct {
+	ingest.HealthTracker
+	client    *Client
+	cursors   ingest.CursorStore
+	subreddit string
+
+	mu       sync.Mutex
+	loaded   bool
+	cursor   string // newest post already stored
+	pending  string // newest post returned by the last Fetch, not yet committed
+	rejected []models.ParseFailure
+}
+
+func NewSource(client *Client, cursors ingest.CursorStore, subreddit string) *Source {
+	return &Source{client: client, cursors: cursors, subreddit: subreddit}
+}
+
+func (s *Source) Name() string {
+	return "reddit/" + s.subreddit
+}
+
+func (s *Source) Fetch(ctx context.Context) ([]models.Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loaded {
+		cursor, err := s.cursors.GetCursor(ctx, s.Name())
+		if err != nil {
+			log.Printf("Error loading cursor for %s, starting from the first page: %v", s.Name(), err)
+		}
+		s.cursor = cursor
+		s.loaded = true
+	}
+
+	s.pending, s.rejected = "", nil
+	goals, rejected, newest, err := FetchGoals(ctx, s.client, s.subreddit, s.cursor)
+	s.Record(err)
+	if err != nil {
+		return nil, err
+	}
+	s.pending, s.rejected = newest, rejected
+	return goals, nil
+}
+
+// Rejected returns the Media posts the last Fetch could not parse.
+func (s *Source) Rejected() []models.ParseFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rejected
+}
+
+// Commit advances the cursor past the posts returned by the last Fetch. It must
+// only be called once those goals are safely stored.
+func (s *Source) Commit(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == "" || s.pending == s.cursor {
+		return nil
+	}
+	if err := s.cursors.SetCursor(ctx, s.Name(), s.pending); err != nil {
+		return err
+	}
+	s.cursor = s.pending
+	return nil
+}
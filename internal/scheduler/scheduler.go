@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"blooters/internal/metrics"
+)
+
+// Job is a named task run periodically by a Scheduler.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Jitter adds a random delay of up to this much to every interval so jobs
+	// don't hit Reddit or Postgres in lockstep.
+	Jitter time.Duration
+	// Timeout bounds a single run; zero means the run may take a full interval.
+	Timeout time.Duration
+	// RunAtStart runs the job immediately instead of after the first interval.
+	RunAtStart bool
+	Run        func(ctx context.Context) error
+}
+
+// Status is a snapshot of a job's schedule and last outcome.
+type Status struct {
+	Name         string
+	Interval     time.Duration
+	Running      bool
+	LastRun      time.Time
+	LastDuration time.Duration
+	LastError    string
+	NextRun      time.Time
+}
+
+type jobState struct {
+	job     Job
+	mu      sync.Mutex
+	status  Status
+	running bool
+}
+
+// Scheduler runs jobs on their intervals. A job never overlaps with itself:
+// if a run is still going when the next one is due, that tick is skipped.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*jobState
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job. Jobs must be added before Run is called.
+func (s *Scheduler) Add(job Job) {
+	if job.Name == "" || job.Interval <= 0 || job.Run == nil {
+		panic(fmt.Sprintf("scheduler: invalid job %q", job.Name))
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = job.Interval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &jobState{
+		job:    job,
+		status: Status{Name: job.Name, Interval: job.Interval},
+	})
+}
+
+// Run starts every job and blocks until ctx is cancelled and all in-flight
+// runs have returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]*jobState(nil), s.jobs...)
+	s.mu.Unlock()
+
+	for _, js := range jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, js)
+		}()
+	}
+	<-ctx.Done()
+	s.wg.Wait()
+}
+
+// Status returns a snapshot of every job, ordered by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Status, 0, len(s.jobs))
+	for _, js := range s.jobs {
+		js.mu.Lock()
+		st := js.status
+		st.Running = js.running
+		js.mu.Unlock()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *Scheduler) loop(ctx context.Context, js *jobState) {
+	var runs sync.WaitGroup
+	defer runs.Wait()
+
+	delay := js.nextDelay()
+	if js.job.RunAtStart {
+		delay = 0
+	}
+
+	for {
+		js.setNextRun(time.Now().Add(delay))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !js.tryStart() {
+			log.Printf("Job %s still running, skipping this run", js.job.Name)
+			metrics.JobRunCount.WithLabelValues(js.job.Name, "skipped").Inc()
+		} else {
+			runs.Add(1)
+			go func() {
+				defer runs.Done()
+				js.execute(ctx)
+			}()
+		}
+		delay = js.nextDelay()
+	}
+}
+
+func (js *jobState) nextDelay() time.Duration {
+	d := js.job.Interval
+	if js.job.Jitter > 0 {
+		d += rand.N(js.job.Jitter)
+	}
+	return d
+}
+
+func (js *jobState) setNextRun(t time.Time) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.status.NextRun = t
+}
+
+func (js *jobState) tryStart() bool {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if js.running {
+		return false
+	}
+	js.running = true
+	return true
+}
+
+func (js *jobState) execute(ctx context.Context) {
+	name := js.job.Name
+	runCtx, cancel := context.WithTimeout(ctx, js.job.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runSafely(runCtx, js.job.Run)
+	duration := time.Since(start)
+
+	status := "success"
+	if err != nil {
+		status = "error"
+		log.Printf("Job %s failed after %v: %v", name, duration, err)
+	}
+	metrics.JobRunCount.WithLabelValues(name, status).Inc()
+	metrics.JobDuration.WithLabelValues(name).Observe(duration.Seconds())
+	if err == nil {
+		metrics.JobLastSuccess.WithLabelValues(name).SetToCurrentTime()
+	}
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.running = false
+	js.status.LastRun = start
+	js.status.LastDuration = duration
+	js.status.LastError = ""
+	if err != nil {
+		js.status.LastError = err.Error()
+	}
+}
+
+// runSafely turns a panicking job into an error so one bad run doesn't take
+// the process down.
+func runSafely(ctx context.Context, run func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
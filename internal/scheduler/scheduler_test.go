@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJobsDoNotOverlap(t *testing.T) {
+	var running, maxRunning, runs atomic.Int32
+
+	s := New()
+	s.Add(Job{
+		Name:       "slow",
+		Interval:   5 * time.Millisecond,
+		Timeout:    time.Second,
+		RunAtStart: true,
+		Run: func(ctx context.Context) error {
+			n := running.Add(1)
+			if n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			time.Sleep(30 * time.Millisecond)
+			running.Add(-1)
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	if got := maxRunning.Load(); got != 1 {
+		t.Errorf("max concurrent runs = %d, want 1", got)
+	}
+	if running.Load() != 0 {
+		t.Error("Run returned while a job was still running")
+	}
+	if runs.Load() == 0 {
+		t.Error("job never ran")
+	}
+}
+
+func TestRunCancelsJobs(t *testing.T) {
+	s := New()
+	s.Add(Job{
+		Name:       "blocking",
+		Interval:   time.Hour,
+		RunAtStart: true,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+
+	st := s.Status()
+	if len(st) != 1 || st[0].LastError == "" || st[0].Running {
+		t.Errorf("Status() = %+v, want one finished job with the cancellation error", st)
+	}
+}
@@ -1,12 +1,19 @@
 package server
 
 import (
+	"blooters/internal/db"
+	"blooters/internal/events"
 	"blooters/internal/handler"
 	"blooters/internal/middleware"
+	"blooters/internal/scheduler"
 	"bytes"
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -18,14 +25,42 @@ import (
 )
 
 type Server struct {
-	mux http.Handler
+	mux      http.Handler
+	http     *http.Server
+	pusher   *metricsPusher
+	shutdown chan struct{} // closed when Shutdown starts, ends open event streams
 }
 
-func NewServer() *Server {
+func NewServer(store *db.Store, broker *events.Broker, sched *scheduler.Scheduler) *Server {
 	mux := http.NewServeMux()
+	shutdown := make(chan struct{})
 
 	mux.HandleFunc("GET /api/ping", handler.PingHandler)
-	mux.HandleFunc("GET /api/games", handler.GamesHandler)
+	// Games change at most once per ingestion run, so their listing is
+	// cached until the next goal, score or mirrors event
+	games := middleware.NewResponseCache(time.Minute, 256)
+	go games.Watch(broker)
+	mux.Handle("GET /api/games", games.Handler(handler.GamesHandler(store)))
+	mux.HandleFunc("GET /api/games/stream", handler.StreamHandler(broker, shutdown))
+	mux.HandleFunc("GET /api/games/{id}", handler.GameHandler(store))
+	mux.HandleFunc("GET /api/goals/{id}", handler.GoalHandler(store))
+	mux.HandleFunc("GET /api/changes", handler.ChangesHandler(store))
+	mux.HandleFunc("GET /api/search", handler.SearchHandler(store))
+	mux.HandleFunc("GET /api/jobs", handler.JobsHandler(sched))
+	mux.HandleFunc("GET /api/teams", handler.TeamsHandler(store))
+	mux.HandleFunc("GET /api/teams/{name}/games", handler.TeamGamesHandler(store))
+	mux.HandleFunc("GET /api/players/{name}/goals", handler.PlayerGoalsHandler(store))
+	mux.HandleFunc("GET /api/", handler.NotFoundHandler)
+
+	// Admin endpoints, authenticated with ADMIN_TOKENS
+	admin := middleware.AdminTokensFromEnv()
+	mux.Handle("POST /api/admin/teams/{id}/aliases", middleware.RequireAdmin(admin, handler.AddTeamAliasHandler(store)))
+	mux.Handle("PATCH /api/goals/{id}", middleware.RequireAdmin(admin, handler.PatchGoalHandler(store)))
+	mux.Handle("PATCH /api/games/{id}", middleware.RequireAdmin(admin, handler.PatchGameHandler(store)))
+	mux.Handle("GET /api/admin/audit/{entity}/{id}", middleware.RequireAdmin(admin, handler.AuditLogHandler(store)))
+	mux.Handle("GET /api/admin/parse-failures", middleware.RequireAdmin(admin, handler.ParseFailuresHandler(store)))
+	mux.Handle("POST /api/admin/parse-failures/reparse", middleware.RequireAdmin(admin, handler.ReparseHandler(store)))
+	mux.Handle("POST /api/admin/parse-failures/{id}/promote", middleware.RequireAdmin(admin, handler.PromoteParseFailureHandler(store)))
 	mux.Handle("/metrics", promhttp.Handler())
 
 	// Create remote write client for Grafana Cloud
@@ -36,67 +71,134 @@ func NewServer() *Server {
 	username := os.Getenv("GRAFANA_USERNAME")
 	password := os.Getenv("GRAFANA_PASSWORD")
 
+	var pusher *metricsPusher
 	if username != "" && password != "" {
-		go pushMetrics(url, username, password)
+		pusher = newMetricsPusher(url, username, password)
+		go pusher.run()
 	}
 
 	//Logging middleware:
 	handler := middleware.LoggingMiddleware(mux)
 
-	return &Server{
-		mux: handler,
+	s := &Server{
+		mux:      handler,
+		pusher:   pusher,
+		shutdown: shutdown,
+	}
+	s.http = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
+	s.http.RegisterOnShutdown(func() { close(s.shutdown) })
+	return s
 }
 
+// Start serves HTTP until Shutdown is called, in which case it returns nil.
 func (s *Server) Start(addr string) error {
 	log.Printf("Server starting on %s", addr)
-	return http.ListenAndServe(addr, s.mux)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish, or for ctx to expire. Open event streams are closed so they don't
+// hold the drain up.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.pusher != nil {
+		s.pusher.stop()
+	}
+	return s.http.Shutdown(ctx)
+}
+
+// FlushMetrics pushes the current metrics to Grafana Cloud one last time. It
+// is meant to be called after the background jobs have stopped.
+func (s *Server) FlushMetrics(ctx context.Context) {
+	if s.pusher != nil {
+		s.pusher.push(ctx)
+	}
+}
+
+type metricsPusher struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
-func pushMetrics(url, username, password string) {
+func newMetricsPusher(url, username, password string) *metricsPusher {
+	return &metricsPusher{
+		url:      url,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *metricsPusher) run() {
 	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
-	for range ticker.C {
-		mfs, err := prometheus.DefaultGatherer.Gather()
-		if err != nil {
-			log.Println("Error gathering metrics:", err)
-			continue
-		}
-		ts := toTimeSeries(mfs)
-		if len(ts) == 0 {
-			continue
-		}
-		req := &prompb.WriteRequest{
-			Timeseries: ts,
-		}
-		data, err := proto.Marshal(req)
-		if err != nil {
-			log.Println("Error marshaling:", err)
-			continue
-		}
-		compressed := snappy.Encode(nil, data)
-		httpClient := &http.Client{Timeout: 30 * time.Second}
-		reqHttp, err := http.NewRequest("POST", url, bytes.NewReader(compressed))
-		if err != nil {
-			log.Println("Error creating request:", err)
-			continue
-		}
-		reqHttp.SetBasicAuth(username, password)
-		reqHttp.Header.Set("Content-Type", "application/x-protobuf")
-		reqHttp.Header.Set("Content-Encoding", "snappy")
-		reqHttp.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
-		resp, err := httpClient.Do(reqHttp)
-		if err != nil {
-			log.Println("Error sending:", err)
-			continue
-		}
-		resp.Body.Close()
-		if resp.StatusCode != 200 {
-			log.Println("Error status:", resp.StatusCode)
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.push(context.Background())
 		}
 	}
 }
 
+func (p *metricsPusher) stop() {
+	p.stopOnce.Do(func() { close(p.done) })
+}
+
+func (p *metricsPusher) push(ctx context.Context) {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		log.Println("Error gathering metrics:", err)
+		return
+	}
+	ts := toTimeSeries(mfs)
+	if len(ts) == 0 {
+		return
+	}
+	req := &prompb.WriteRequest{
+		Timeseries: ts,
+	}
+	data, err := proto.Marshal(req)
+	if err != nil {
+		log.Println("Error marshaling:", err)
+		return
+	}
+	compressed := snappy.Encode(nil, data)
+	reqHttp, err := http.NewRequestWithContext(ctx, "POST", p.url, bytes.NewReader(compressed))
+	if err != nil {
+		log.Println("Error creating request:", err)
+		return
+	}
+	reqHttp.SetBasicAuth(p.username, p.password)
+	reqHttp.Header.Set("Content-Type", "application/x-protobuf")
+	reqHttp.Header.Set("Content-Encoding", "snappy")
+	reqHttp.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := p.client.Do(reqHttp)
+	if err != nil {
+		log.Println("Error sending:", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.Println("Error status:", resp.StatusCode)
+	}
+}
+
 func toTimeSeries(mfs []*dto.MetricFamily) []prompb.TimeSeries {
 	var ts []prompb.TimeSeries
 	for _, mf := range mfs {